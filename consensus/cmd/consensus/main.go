@@ -0,0 +1,40 @@
+// Command consensus runs a command as a cluster-wide singleton.
+//
+// Usage:
+//
+//	consensus run [flags] -- <command> [args...]
+//
+// The process joins the election with the chosen backend and starts the
+// command only while it is the leader. When leadership is lost the command
+// receives SIGTERM, then SIGKILL after the grace period. The command sees its
+// leadership through environment variables:
+//
+//	CONSENSUS_IDENTITY        identity of this instance
+//	CONSENSUS_TERM            fencing term of the current leadership
+//	CONSENSUS_LEASE_DURATION  lease duration, e.g. "15s"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "run" {
+		fmt.Fprintln(os.Stderr, "Usage: consensus run [flags] -- <command> [args...]")
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	opts := registerRunFlags(fs)
+	fs.Parse(os.Args[2:])
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "consensus run: no command given")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	os.Exit(run(opts, fs.Args()))
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+	"github.com/fraser/consensus/pkg/consensus/backends/lease"
+	"github.com/fraser/consensus/pkg/consensus/backends/remote"
+)
+
+// leadershipPollInterval is how often the supervisor checks for lost leadership.
+const leadershipPollInterval = 100 * time.Millisecond
+
+// Exit policies applied when the child exits while this instance is leader.
+const (
+	policyExit     = "exit"     // stop supervising and exit with the child's code
+	policyRestart  = "restart"  // restart the child with backoff
+	policyStepDown = "stepdown" // release leadership so another instance runs it
+)
+
+// runOptions holds the flags of the run command.
+type runOptions struct {
+	backend       string
+	path          string
+	leaseName     string
+	remoteURL     string
+	identity      string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+	retryInterval time.Duration
+	grace         time.Duration
+	onSuccess     string
+	onFailure     string
+	backoff       time.Duration
+	maxBackoff    time.Duration
+}
+
+func registerRunFlags(fs *flag.FlagSet) *runOptions {
+	defaults := consensus.NewConfig("")
+	opts := &runOptions{}
+
+	fs.StringVar(&opts.backend, "backend", "file", "backend to elect with: file, lease or remote")
+	fs.StringVar(&opts.path, "path", "/tmp/consensus-lease.json", "lease file path (file)")
+	fs.StringVar(&opts.leaseName, "lease-name", "consensus-leader", "Lease object name (lease, namespace from POD_NAMESPACE)")
+	fs.StringVar(&opts.remoteURL, "remote-url", "http://localhost:8080", "consensusd URL (remote, token from CONSENSUS_REMOTE_TOKEN)")
+	fs.StringVar(&opts.identity, "identity", defaultIdentity(), "identity of this instance")
+	fs.DurationVar(&opts.leaseDuration, "lease-duration", defaults.LeaseDuration, "how long a lease is valid before expiring")
+	fs.DurationVar(&opts.renewInterval, "renew-interval", defaults.RenewInterval, "how often the leader renews its lease")
+	fs.DurationVar(&opts.retryInterval, "retry-interval", defaults.RetryInterval, "how often non-leaders retry acquiring leadership")
+	fs.DurationVar(&opts.grace, "grace", 10*time.Second, "time between SIGTERM and SIGKILL when stopping the command")
+	fs.StringVar(&opts.onSuccess, "on-success", policyExit, "when the command exits 0 while leader: exit, restart or stepdown")
+	fs.StringVar(&opts.onFailure, "on-failure", policyRestart, "when the command exits non-zero while leader: exit, restart or stepdown")
+	fs.DurationVar(&opts.backoff, "backoff", time.Second, "initial delay before restarting the command")
+	fs.DurationVar(&opts.maxBackoff, "max-backoff", 30*time.Second, "maximum delay before restarting the command")
+
+	return opts
+}
+
+// defaultIdentity returns POD_NAME, or hostname-pid so that several
+// supervisors on one host campaign as distinct instances.
+func defaultIdentity() string {
+	if pod := os.Getenv("POD_NAME"); pod != "" {
+		return pod
+	}
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// newBackend creates the backend selected on the command line.
+func newBackend(opts *runOptions) (consensus.Backend, error) {
+	switch opts.backend {
+	case "file":
+		return file.NewBackend(opts.path), nil
+	case "lease":
+		// The --lease-duration flag takes precedence over CONSENSUS_LEASE_TTL
+		return lease.NewFromEnv(opts.leaseName, lease.WithTTL(opts.leaseDuration))
+	case "remote":
+		return remote.NewBackend(opts.remoteURL, remote.WithToken(os.Getenv("CONSENSUS_REMOTE_TOKEN"))), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", opts.backend)
+	}
+}
+
+// run supervises the command and returns the process exit code.
+func run(opts *runOptions, command []string) int {
+	for _, policy := range []string{opts.onSuccess, opts.onFailure} {
+		switch policy {
+		case policyExit, policyRestart, policyStepDown:
+		default:
+			log.Printf("Invalid exit policy %q", policy)
+			return 2
+		}
+	}
+
+	backend, err := newBackend(opts)
+	if err != nil {
+		log.Printf("Failed to create backend: %v", err)
+		return 1
+	}
+
+	manager := consensus.NewManager(backend, consensus.Config{
+		Identity:      opts.identity,
+		LeaseDuration: opts.leaseDuration,
+		RenewInterval: opts.renewInterval,
+		RetryInterval: opts.retryInterval,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	lease := manager.Start(ctx)
+	defer manager.Stop()
+
+	log.Printf("[%s] Joining election with %s backend", opts.identity, opts.backend)
+
+	backoff := opts.backoff
+	for {
+		if err := lease.WaitForLeadership(ctx); err != nil {
+			return 0
+		}
+
+		log.Printf("[%s] Became leader (term %d), starting %q", opts.identity, lease.Term(), command[0])
+		started := time.Now()
+		code, lost := supervise(ctx, opts, lease, command)
+
+		if ctx.Err() != nil {
+			return 0
+		}
+		if lost {
+			log.Printf("[%s] Lost leadership, command stopped", opts.identity)
+			backoff = opts.backoff
+			continue
+		}
+
+		// The command exited on its own while we were still leader
+		policy := opts.onFailure
+		if code == 0 {
+			policy = opts.onSuccess
+		}
+		log.Printf("[%s] Command exited with code %d, policy %s", opts.identity, code, policy)
+
+		switch policy {
+		case policyExit:
+			return code
+		case policyStepDown:
+			manager.StepDown()
+			waitForDemotion(ctx, lease)
+		case policyRestart:
+			// A command that ran for a while has recovered; start over from the initial backoff
+			if time.Since(started) > opts.maxBackoff {
+				backoff = opts.backoff
+			}
+			select {
+			case <-ctx.Done():
+				return 0
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, opts.maxBackoff)
+		}
+	}
+}
+
+// supervise runs the command until it exits, leadership is lost, or ctx is cancelled.
+// Returns the command's exit code and whether it was stopped because leadership was lost.
+func supervise(ctx context.Context, opts *runOptions, lease *consensus.Lease, command []string) (int, bool) {
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"CONSENSUS_IDENTITY="+opts.identity,
+		"CONSENSUS_TERM="+strconv.FormatUint(lease.Term(), 10),
+		"CONSENSUS_LEASE_DURATION="+opts.leaseDuration.String(),
+	)
+	// Own process group, so shell scripts and their children are signalled together
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		log.Printf("[%s] Failed to start command: %v", opts.identity, err)
+		return 127, false
+	}
+
+	exited := make(chan int, 1)
+	go func() {
+		exited <- exitCode(cmd.Wait())
+	}()
+
+	ticker := time.NewTicker(leadershipPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case code := <-exited:
+			return code, false
+		case <-ctx.Done():
+			return terminate(cmd, exited, opts.grace), false
+		case <-ticker.C:
+			if !lease.IsLeader() {
+				return terminate(cmd, exited, opts.grace), true
+			}
+		}
+	}
+}
+
+// terminate sends SIGTERM to the command's process group, then SIGKILL after grace.
+func terminate(cmd *exec.Cmd, exited <-chan int, grace time.Duration) int {
+	pgid := -cmd.Process.Pid
+	_ = syscall.Kill(pgid, syscall.SIGTERM)
+
+	select {
+	case code := <-exited:
+		return code
+	case <-time.After(grace):
+		_ = syscall.Kill(pgid, syscall.SIGKILL)
+		return <-exited
+	}
+}
+
+// exitCode extracts the exit code from cmd.Wait's error.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+// waitForDemotion blocks until the manager has released leadership.
+func waitForDemotion(ctx context.Context, lease *consensus.Lease) {
+	ticker := time.NewTicker(leadershipPollInterval)
+	defer ticker.Stop()
+
+	for lease.IsLeader() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
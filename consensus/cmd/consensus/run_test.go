@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+// testOptions returns run options electing on a file backend in a temporary directory.
+func testOptions(t *testing.T) *runOptions {
+	t.Helper()
+
+	return &runOptions{
+		backend:       "file",
+		path:          filepath.Join(t.TempDir(), "lease.json"),
+		identity:      "a",
+		leaseDuration: 300 * time.Millisecond,
+		renewInterval: 50 * time.Millisecond,
+		retryInterval: 20 * time.Millisecond,
+		grace:         time.Second,
+		onSuccess:     policyExit,
+		onFailure:     policyExit,
+		backoff:       50 * time.Millisecond,
+		maxBackoff:    time.Second,
+	}
+}
+
+// shell returns a command running script with sh.
+func shell(script string) []string {
+	return []string{"sh", "-c", script}
+}
+
+// readLines returns the lines of the file at path.
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(raw)), "\n")
+}
+
+func TestRunPassesLeadershipEnvironment(t *testing.T) {
+	opts := testOptions(t)
+	out := filepath.Join(t.TempDir(), "env")
+
+	if code := run(opts, shell("env > "+out)); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+
+	env := readLines(t, out)
+	for _, want := range []string{
+		"CONSENSUS_IDENTITY=a",
+		"CONSENSUS_TERM=1",
+		"CONSENSUS_LEASE_DURATION=300ms",
+	} {
+		found := false
+		for _, line := range env {
+			found = found || line == want
+		}
+		if !found {
+			t.Errorf("child environment is missing %s", want)
+		}
+	}
+}
+
+func TestSuperviseKillsChildIgnoringSIGTERM(t *testing.T) {
+	opts := testOptions(t)
+	opts.grace = 200 * time.Millisecond
+	ready := filepath.Join(t.TempDir(), "ready")
+
+	manager := consensus.NewManager(file.NewBackend(opts.path), consensus.Config{
+		Identity:      opts.identity,
+		LeaseDuration: opts.leaseDuration,
+		RenewInterval: opts.renewInterval,
+		RetryInterval: opts.retryInterval,
+	})
+	lease := manager.Start(context.Background())
+	defer manager.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := lease.WaitForLeadership(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stop supervising once the child ignores SIGTERM; the ignored signal is
+	// inherited by sleep, so only SIGKILL stops the group
+	var stopped time.Time
+	go func() {
+		for {
+			if _, err := os.Stat(ready); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		stopped = time.Now()
+		cancel()
+	}()
+
+	code, lost := supervise(ctx, opts, lease, shell("trap '' TERM; touch "+ready+"; sleep 30"))
+	elapsed := time.Since(stopped)
+
+	if lost {
+		t.Fatal("supervise reported lost leadership, want cancellation")
+	}
+	if want := 128 + int(syscall.SIGKILL); code != want {
+		t.Fatalf("exit code = %d, want %d", code, want)
+	}
+	if elapsed < opts.grace {
+		t.Fatalf("child killed %v after SIGTERM, want at least the %v grace period", elapsed, opts.grace)
+	}
+}
+
+func TestRunRestartsWithBackoff(t *testing.T) {
+	opts := testOptions(t)
+	opts.onFailure = policyRestart
+	runs := filepath.Join(t.TempDir(), "runs")
+
+	// Fail three times, then succeed and exit
+	started := time.Now()
+	code := run(opts, shell("echo run >> "+runs+"; [ $(wc -l < "+runs+") -ge 4 ] && exit 0; exit 1"))
+	elapsed := time.Since(started)
+
+	if code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if n := len(readLines(t, runs)); n != 4 {
+		t.Fatalf("command ran %d times, want 4", n)
+	}
+	// Backoff doubles from 50ms: 50ms + 100ms + 200ms
+	if want := 350 * time.Millisecond; elapsed < want {
+		t.Fatalf("three restarts took %v, want at least %v", elapsed, want)
+	}
+}
+
+func TestRunExitPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		script string
+		want   int
+	}{
+		{name: "Success", script: "exit 0", want: 0},
+		{name: "Failure", script: "exit 3", want: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := testOptions(t)
+			opts.onSuccess = policyExit
+			opts.onFailure = policyExit
+
+			if code := run(opts, shell(tc.script)); code != tc.want {
+				t.Fatalf("exit code = %d, want %d", code, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunStepDownPolicy(t *testing.T) {
+	opts := testOptions(t)
+	opts.onSuccess = policyStepDown
+	opts.onFailure = policyExit
+	terms := filepath.Join(t.TempDir(), "terms")
+
+	// Step down after the first run, then fail on the next to stop run
+	code := run(opts, shell("echo $CONSENSUS_TERM >> "+terms+"; [ $(wc -l < "+terms+") -ge 2 ] && exit 5; exit 0"))
+	if code != 5 {
+		t.Fatalf("exit code = %d, want 5", code)
+	}
+	if got := strings.Join(readLines(t, terms), ","); got != "1,2" {
+		t.Fatalf("command ran in terms %s, want 1,2", got)
+	}
+
+	history, err := file.NewBackend(opts.path).History(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) == 0 || history[0].Reason != consensus.EndReleased {
+		t.Fatalf("history = %+v, want the first term released", history)
+	}
+}
+
+func TestRunRejectsUnknownPolicy(t *testing.T) {
+	opts := testOptions(t)
+	opts.onFailure = "retry"
+
+	if code := run(opts, shell("exit 0")); code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+}
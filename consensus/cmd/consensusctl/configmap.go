@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// configMapTarget reads the ConfigMap format used by the standalone demo in
+// main.go: data.leader holds the identity and data.lastUpdated the RFC3339
+// renewal time. The format stores no TTL or acquire time.
+type configMapTarget struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	ttl       time.Duration
+}
+
+func newConfigMapTarget(client kubernetes.Interface, namespace, name string, ttl time.Duration) *configMapTarget {
+	return &configMapTarget{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		ttl:       ttl,
+	}
+}
+
+// GetLeader returns the holder recorded in the ConfigMap, or nil if none or expired.
+func (c *configMapTarget) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	cm, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get leader ConfigMap: %w", err)
+	}
+
+	leader := cm.Data["leader"]
+	if leader == "" {
+		return nil, nil
+	}
+
+	renewed, err := time.Parse(time.RFC3339, cm.Data["lastUpdated"])
+	if err != nil || time.Since(renewed) >= c.ttl {
+		return nil, nil
+	}
+
+	return &consensus.LeaderRecord{
+		Identity:      leader,
+		RenewTime:     renewed,
+		LeaseDuration: c.ttl,
+	}, nil
+}
+
+// ForceRelease clears the leader field.
+func (c *configMapTarget) ForceRelease(ctx context.Context) error {
+	return c.update(ctx, func(data map[string]string) {
+		data["leader"] = ""
+	})
+}
+
+// Transfer sets the leader field to identity with a fresh renewal time.
+func (c *configMapTarget) Transfer(ctx context.Context, identity string) error {
+	return c.update(ctx, func(data map[string]string) {
+		data["leader"] = identity
+		data["lastUpdated"] = time.Now().Format(time.RFC3339)
+	})
+}
+
+// update applies fn to the ConfigMap data, retrying on conflicts.
+func (c *configMapTarget) update(ctx context.Context, fn func(map[string]string)) error {
+	configMaps := c.client.CoreV1().ConfigMaps(c.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := configMaps.Get(ctx, c.name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get leader ConfigMap: %w", err)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		fn(cm.Data)
+
+		_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -0,0 +1,357 @@
+// Command consensusctl inspects and intervenes on consensus leases.
+//
+// Usage:
+//
+//	consensusctl [-backend file|lease|configmap] [backend flags] <command> [flags]
+//
+// Commands:
+//
+//	status                 show the current holder, age and remaining TTL
+//	watch [-interval 1s]   print a line whenever the holder changes
+//	release --force        clear the lease regardless of who holds it
+//	transfer --to ID       hand the lease to another identity
+//	history                show past leaders, how long they led and why they stopped
+//	members                list the live participants and their last heartbeat
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+	"github.com/fraser/consensus/pkg/consensus/backends/lease"
+)
+
+// target is a lease store the CLI can inspect and modify.
+type target interface {
+	consensus.LeaderReader
+	consensus.Administrator
+}
+
+func main() {
+	backendName := flag.String("backend", "file", "lease format: file, lease or configmap")
+	path := flag.String("path", "/tmp/consensus-lease.json", "lease file path (file)")
+	name := flag.String("name", "consensus-leader", "Lease or ConfigMap name (lease, configmap)")
+	namespace := flag.String("namespace", "default", "namespace of the Lease or ConfigMap (lease, configmap)")
+	kubeconfig := flag.String("kubeconfig", "", "path to kubeconfig (default: in-cluster, then $KUBECONFIG or ~/.kube/config)")
+	ttl := flag.Duration("ttl", 5*time.Second, "lease validity of the ConfigMap format, which does not store it (configmap)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	t, err := newTarget(*backendName, *path, *namespace, *name, *kubeconfig, *ttl)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	switch cmd {
+	case "status":
+		err = runStatus(ctx, t)
+	case "watch":
+		err = runWatch(ctx, t, args)
+	case "release":
+		err = runRelease(ctx, t, args)
+	case "transfer":
+		err = runTransfer(ctx, t, args)
+	case "history":
+		err = runHistory(ctx, t)
+	case "members":
+		err = runMembers(ctx, t)
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatalf("%s: %v", cmd, err)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: consensusctl [flags] <status|watch|release|transfer|history|members> [command flags]\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "consensusctl: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// newTarget opens the lease store selected on the command line.
+func newTarget(backend, path, namespace, name, kubeconfig string, ttl time.Duration) (target, error) {
+	switch backend {
+	case "file":
+		return file.NewBackend(path), nil
+	case "lease":
+		client, err := newKubernetesClient(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		return lease.NewBackend(client, namespace, name), nil
+	case "configmap":
+		client, err := newKubernetesClient(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		return newConfigMapTarget(client, namespace, name, ttl), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", backend)
+	}
+}
+
+// newKubernetesClient creates a clientset from in-cluster config or a kubeconfig file.
+func newKubernetesClient(kubeconfig string) (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil || kubeconfig != "" {
+		if kubeconfig == "" {
+			kubeconfig = os.Getenv("KUBECONFIG")
+		}
+		if kubeconfig == "" {
+			kubeconfig = os.ExpandEnv("$HOME/.kube/config")
+		}
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes config: %w", err)
+		}
+	}
+
+	return kubernetes.NewForConfig(config)
+}
+
+// runStatus prints the current holder.
+func runStatus(ctx context.Context, t target) error {
+	record, err := t.GetLeader(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "HOLDER\tSESSION\tADDRESS\tAGE\tRENEWED\tTTL LEFT\tTRANSITIONS")
+	if record == nil {
+		fmt.Fprintln(w, "<none>\t\t\t\t\t\t")
+	} else {
+		now := time.Now()
+		identity, session := consensus.SplitHolder(record.Identity)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\t%d\n",
+			identity,
+			orDash(session),
+			orDash(record.Address),
+			since(now, record.AcquireTime),
+			since(now, record.RenewTime),
+			remaining(now, record),
+			record.Transitions,
+		)
+	}
+	return w.Flush()
+}
+
+// runWatch prints a line whenever the holder changes.
+func runWatch(ctx context.Context, t target, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", time.Second, "how often to poll the lease")
+	fs.Parse(args)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	first := true
+	var last string
+	for {
+		record, err := t.GetLeader(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s  error: %v\n", time.Now().Format(time.RFC3339), err)
+		} else {
+			key := "<none>"
+			if record != nil {
+				key = record.Identity
+			}
+			if first || key != last {
+				printChange(record)
+				first = false
+				last = key
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// printChange prints one watch line.
+func printChange(record *consensus.LeaderRecord) {
+	now := time.Now()
+	if record == nil {
+		fmt.Printf("%s  leader=<none>\n", now.Format(time.RFC3339))
+		return
+	}
+	identity, session := consensus.SplitHolder(record.Identity)
+	fmt.Printf("%s  leader=%s session=%s address=%s transitions=%d ttl-left=%s\n",
+		now.Format(time.RFC3339), identity, orDash(session), orDash(record.Address), record.Transitions, remaining(now, record))
+}
+
+// runRelease clears the lease.
+func runRelease(ctx context.Context, t target, args []string) error {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	force := fs.Bool("force", false, "clear the lease even though this process does not hold it")
+	fs.Parse(args)
+
+	if !*force {
+		return fmt.Errorf("refusing to release another process's lease without --force")
+	}
+
+	if err := t.ForceRelease(ctx); err != nil {
+		return err
+	}
+	fmt.Println("lease released")
+	return nil
+}
+
+// runTransfer hands the lease to another identity.
+func runTransfer(ctx context.Context, t target, args []string) error {
+	fs := flag.NewFlagSet("transfer", flag.ExitOnError)
+	to := fs.String("to", "", "identity to hand the lease to; the process running with it claims the lease")
+	fs.Parse(args)
+
+	if *to == "" {
+		return fmt.Errorf("--to is required")
+	}
+
+	if err := t.Transfer(ctx, *to); err != nil {
+		return err
+	}
+	fmt.Printf("lease transferred to %s\n", *to)
+	return nil
+}
+
+// runHistory prints the recorded tenures, oldest first, then the current holder.
+// Stores that keep no history only report the transition count.
+func runHistory(ctx context.Context, t target) error {
+	record, err := t.GetLeader(ctx)
+	if err != nil {
+		return err
+	}
+
+	hr, ok := t.(consensus.HistoryReader)
+	if !ok {
+		if record == nil {
+			fmt.Println("no current leader")
+			return nil
+		}
+		fmt.Printf("transitions: %d\n", record.Transitions)
+		fmt.Printf("current:     %s since %s (%s)\n",
+			record.Identity, record.AcquireTime.Format(time.RFC3339), since(time.Now(), record.AcquireTime))
+		return nil
+	}
+
+	history, err := hr.History(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TERM\tHOLDER\tSESSION\tACQUIRED\tENDED\tLED FOR\tREASON\tNEXT")
+	for _, tenure := range history {
+		identity, session := consensus.SplitHolder(tenure.Identity)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			tenure.Term,
+			identity,
+			orDash(session),
+			tenure.AcquireTime.Format(time.RFC3339),
+			tenure.EndTime.Format(time.RFC3339),
+			since(tenure.EndTime, tenure.AcquireTime),
+			tenure.Reason,
+			orDash(tenure.Successor),
+		)
+	}
+	if record != nil {
+		identity, session := consensus.SplitHolder(record.Identity)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t-\t%s\tcurrent\t-\n",
+			record.Transitions,
+			identity,
+			orDash(session),
+			record.AcquireTime.Format(time.RFC3339),
+			since(time.Now(), record.AcquireTime),
+		)
+	}
+	return w.Flush()
+}
+
+// runMembers lists every participant that has heartbeated recently.
+func runMembers(ctx context.Context, t target) error {
+	ms, ok := t.(consensus.Membership)
+	if !ok {
+		return fmt.Errorf("%w: backend does not track members", errors.ErrUnsupported)
+	}
+	members, err := ms.Members(ctx)
+	if err != nil {
+		return err
+	}
+	record, err := t.GetLeader(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "IDENTITY\tSESSION\tLEADER\tPRIORITY\tVERSION\tADDRESS\tLAST SEEN")
+	now := time.Now()
+	for _, member := range members {
+		leader := record != nil && record.Identity == member.Identity
+		identity, session := consensus.SplitHolder(member.Identity)
+		fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%s\t%s\t%s ago\n",
+			identity,
+			orDash(session),
+			leader,
+			member.Priority,
+			orDash(member.Version),
+			orDash(member.Address),
+			since(now, member.LastSeen),
+		)
+	}
+	return w.Flush()
+}
+
+// since formats the time elapsed from t to now.
+func since(now, t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return now.Sub(t).Round(100 * time.Millisecond).String()
+}
+
+// remaining formats how long the lease stays valid without another renewal.
+func remaining(now time.Time, record *consensus.LeaderRecord) string {
+	left := record.RenewTime.Add(record.LeaseDuration).Sub(now)
+	if left < 0 {
+		return "expired"
+	}
+	return left.Round(100 * time.Millisecond).String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+// Command consensusd serves a consensus.Backend over the LockService API so
+// that clients in other runtimes, or on hosts without access to the store,
+// can take part in the same election through backends/remote.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+	"github.com/fraser/consensus/pkg/consensus/backends/lease"
+	"github.com/fraser/consensus/pkg/consensus/lockserver"
+)
+
+func main() {
+	listen := flag.String("listen", ":8080", "address to serve the LockService on")
+	backendName := flag.String("backend", "file", "backend to expose: file or lease")
+	filePath := flag.String("file-path", "/tmp/consensus-lease.json", "lease file path (file backend)")
+	leaseName := flag.String("lease-name", "consensus-leader", "Lease object name (lease backend, namespace from POD_NAMESPACE)")
+	tokensFile := flag.String("tokens-file", "", "file with one accepted bearer token per line (also CONSENSUSD_TOKENS, comma-separated)")
+	sessionTTL := flag.Duration("session-ttl", lockserver.DefaultSessionTTL, "maximum time a disconnected client keeps its leases")
+	flag.Parse()
+
+	backend, err := newBackend(*backendName, *filePath, *leaseName)
+	if err != nil {
+		log.Fatalf("Failed to create backend: %v", err)
+	}
+
+	tokens, err := loadTokens(*tokensFile)
+	if err != nil {
+		log.Fatalf("Failed to load tokens: %v", err)
+	}
+	if len(tokens) == 0 {
+		log.Println("No tokens configured, accepting unauthenticated clients")
+	}
+
+	server := lockserver.New(backend,
+		lockserver.WithTokens(tokens...),
+		lockserver.WithMaxSessionTTL(*sessionTTL),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle(server.Handler())
+
+	// Serve HTTP/1.1 and cleartext HTTP/2 so both Connect and gRPC clients work
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	srv := &http.Server{
+		Addr:      *listen,
+		Handler:   mux,
+		Protocols: protocols,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Received shutdown signal")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving %s backend on %s", *backendName, *listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
+
+// newBackend creates the backend selected on the command line.
+func newBackend(name, filePath, leaseName string) (consensus.Backend, error) {
+	switch name {
+	case "file":
+		return file.NewBackend(filePath), nil
+	case "lease":
+		return lease.NewFromEnv(leaseName)
+	default:
+		return nil, fmt.Errorf("unknown backend %q", name)
+	}
+}
+
+// loadTokens reads bearer tokens from the tokens file and CONSENSUSD_TOKENS.
+func loadTokens(path string) ([]string, error) {
+	var tokens []string
+
+	if env := os.Getenv("CONSENSUSD_TOKENS"); env != "" {
+		for _, t := range strings.Split(env, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(raw), "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				tokens = append(tokens, line)
+			}
+		}
+	}
+
+	return tokens, nil
+}
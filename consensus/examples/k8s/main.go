@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,20 +14,27 @@ import (
 )
 
 func main() {
+	base := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
 	// Get pod identity from environment
 	podName := os.Getenv("POD_NAME")
 	if podName == "" {
-		log.Fatal("POD_NAME environment variable must be set")
+		base.Error("POD_NAME environment variable must be set")
+		os.Exit(1)
 	}
+	logger := base.With("identity", podName)
 
 	// Create lease backend using environment config
 	backend, err := lease.NewFromEnv("consensus-worker-leader")
 	if err != nil {
-		log.Fatalf("Failed to create backend: %v", err)
+		logger.Error("failed to create backend", "error", err)
+		os.Exit(1)
 	}
 
-	// Create manager with default config
-	manager := consensus.NewManager(backend, consensus.NewConfig(podName))
+	// Create manager with default config; it logs leadership transitions itself
+	config := consensus.NewConfig(podName)
+	config.Logger = base
+	manager := consensus.NewManager(backend, config)
 
 	// Handle graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -37,7 +44,7 @@ func main() {
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		log.Println("Received shutdown signal")
+		logger.Info("received shutdown signal")
 		cancel()
 	}()
 
@@ -45,29 +52,48 @@ func main() {
 	lease := manager.Start(ctx)
 	defer manager.Stop()
 
-	log.Printf("Starting leader election as %s", podName)
+	logger.Info("starting leader election", "holder", manager.HolderID())
+	printHistory(ctx, logger, manager)
 
 	// Main work loop
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Shutting down")
+			logger.Info("shutting down")
 			return
 		default:
 			if !lease.IsLeader() {
-				log.Printf("[%s] Waiting to become leader...", podName)
+				logger.Info("waiting to become leader")
 				time.Sleep(2 * time.Second)
 				continue
 			}
 
 			// Do leader work
-			log.Printf("[%s] I am the leader! Doing work...", podName)
+			logger.Info("leading, doing work", "term", lease.Term())
 			doWork()
 			time.Sleep(5 * time.Second)
 		}
 	}
 }
 
+// printHistory logs who led before this instance started and why they stopped.
+func printHistory(ctx context.Context, logger *slog.Logger, manager *consensus.Manager) {
+	history, err := manager.History(ctx)
+	if err != nil {
+		logger.Warn("leadership history unavailable", "error", err)
+		return
+	}
+	for _, tenure := range history {
+		logger.Info("previous leader",
+			"leader", tenure.Identity,
+			"session", tenure.Session,
+			"term", tenure.Term,
+			"acquired", tenure.AcquireTime,
+			"ended", tenure.EndTime,
+			"reason", tenure.Reason)
+	}
+}
+
 func doWork() {
 	// Simulate some work
 	fmt.Println("Processing jobs...")
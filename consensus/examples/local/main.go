@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,19 +14,24 @@ import (
 )
 
 func main() {
+	base := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
 	// Get process identity (default to hostname)
 	identity := os.Getenv("INSTANCE_ID")
 	if identity == "" {
 		hostname, _ := os.Hostname()
 		identity = hostname
 	}
+	logger := base.With("identity", identity)
 
 	// Create file backend in /tmp
 	leasePath := "/tmp/consensus-lease.json"
 	backend := file.NewBackend(leasePath)
 
-	// Create manager with default config
-	manager := consensus.NewManager(backend, consensus.NewConfig(identity))
+	// Create manager with default config; it logs leadership transitions itself
+	config := consensus.NewConfig(identity)
+	config.Logger = base
+	manager := consensus.NewManager(backend, config)
 
 	// Handle graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -36,7 +41,7 @@ func main() {
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		log.Println("Received shutdown signal")
+		logger.Info("received shutdown signal")
 		cancel()
 	}()
 
@@ -44,29 +49,49 @@ func main() {
 	lease := manager.Start(ctx)
 	defer manager.Stop()
 
-	log.Printf("Starting leader election as %s (lease file: %s)", identity, leasePath)
+	// Processes sharing a hostname still get distinct holder IDs
+	logger.Info("starting leader election", "holder", manager.HolderID(), "lease_file", leasePath)
+	printHistory(ctx, logger, manager)
 
 	// Main work loop
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Shutting down")
+			logger.Info("shutting down")
 			return
 		default:
 			if !lease.IsLeader() {
-				log.Printf("[%s] Waiting to become leader...", identity)
+				logger.Info("waiting to become leader")
 				time.Sleep(2 * time.Second)
 				continue
 			}
 
 			// Do leader work
-			log.Printf("[%s] I am the leader! Doing work...", identity)
+			logger.Info("leading, doing work", "term", lease.Term())
 			doWork()
 			time.Sleep(5 * time.Second)
 		}
 	}
 }
 
+// printHistory logs who led before this instance started and why they stopped.
+func printHistory(ctx context.Context, logger *slog.Logger, manager *consensus.Manager) {
+	history, err := manager.History(ctx)
+	if err != nil {
+		logger.Warn("leadership history unavailable", "error", err)
+		return
+	}
+	for _, tenure := range history {
+		logger.Info("previous leader",
+			"leader", tenure.Identity,
+			"session", tenure.Session,
+			"term", tenure.Term,
+			"acquired", tenure.AcquireTime,
+			"ended", tenure.EndTime,
+			"reason", tenure.Reason)
+	}
+}
+
 func doWork() {
 	// Simulate some work
 	fmt.Println("Processing jobs...")
@@ -0,0 +1,324 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: consensus/v1/consensus.proto
+
+package consensusv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/fraser/consensus/gen/consensus/v1"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// LockServiceName is the fully-qualified name of the LockService service.
+	LockServiceName = "consensus.v1.LockService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// LockServiceTryAcquireProcedure is the fully-qualified name of the LockService's TryAcquire RPC.
+	LockServiceTryAcquireProcedure = "/consensus.v1.LockService/TryAcquire"
+	// LockServiceRenewProcedure is the fully-qualified name of the LockService's Renew RPC.
+	LockServiceRenewProcedure = "/consensus.v1.LockService/Renew"
+	// LockServiceReleaseProcedure is the fully-qualified name of the LockService's Release RPC.
+	LockServiceReleaseProcedure = "/consensus.v1.LockService/Release"
+	// LockServiceSetAddressProcedure is the fully-qualified name of the LockService's SetAddress RPC.
+	LockServiceSetAddressProcedure = "/consensus.v1.LockService/SetAddress"
+	// LockServiceGetLeaderProcedure is the fully-qualified name of the LockService's GetLeader RPC.
+	LockServiceGetLeaderProcedure = "/consensus.v1.LockService/GetLeader"
+	// LockServiceHistoryProcedure is the fully-qualified name of the LockService's History RPC.
+	LockServiceHistoryProcedure = "/consensus.v1.LockService/History"
+	// LockServiceWatchProcedure is the fully-qualified name of the LockService's Watch RPC.
+	LockServiceWatchProcedure = "/consensus.v1.LockService/Watch"
+	// LockServiceKeepAliveProcedure is the fully-qualified name of the LockService's KeepAlive RPC.
+	LockServiceKeepAliveProcedure = "/consensus.v1.LockService/KeepAlive"
+)
+
+// LockServiceClient is a client for the consensus.v1.LockService service.
+type LockServiceClient interface {
+	// TryAcquire attempts to acquire or renew leadership.
+	TryAcquire(context.Context, *connect.Request[v1.TryAcquireRequest]) (*connect.Response[v1.TryAcquireResponse], error)
+	// Renew extends the current leader's lease.
+	Renew(context.Context, *connect.Request[v1.RenewRequest]) (*connect.Response[v1.RenewResponse], error)
+	// Release explicitly gives up leadership.
+	Release(context.Context, *connect.Request[v1.ReleaseRequest]) (*connect.Response[v1.ReleaseResponse], error)
+	// SetAddress records the address at which the lease holder serves requests.
+	SetAddress(context.Context, *connect.Request[v1.SetAddressRequest]) (*connect.Response[v1.SetAddressResponse], error)
+	// GetLeader returns the current lease holder.
+	GetLeader(context.Context, *connect.Request[v1.GetLeaderRequest]) (*connect.Response[v1.GetLeaderResponse], error)
+	// History returns the recorded past tenures, oldest first.
+	History(context.Context, *connect.Request[v1.HistoryRequest]) (*connect.Response[v1.HistoryResponse], error)
+	// Watch streams the lease holder whenever it changes.
+	Watch(context.Context, *connect.Request[v1.WatchRequest]) (*connect.ServerStreamForClient[v1.WatchResponse], error)
+	// KeepAlive holds a client session open. When the stream ends and the
+	// client does not reconnect within the session TTL, the server releases
+	// every lease acquired through the session.
+	KeepAlive(context.Context, *connect.Request[v1.KeepAliveRequest]) (*connect.ServerStreamForClient[v1.KeepAliveResponse], error)
+}
+
+// NewLockServiceClient constructs a client for the consensus.v1.LockService service. By default, it
+// uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and sends
+// uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC() or
+// connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewLockServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) LockServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	lockServiceMethods := v1.File_consensus_v1_consensus_proto.Services().ByName("LockService").Methods()
+	return &lockServiceClient{
+		tryAcquire: connect.NewClient[v1.TryAcquireRequest, v1.TryAcquireResponse](
+			httpClient,
+			baseURL+LockServiceTryAcquireProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("TryAcquire")),
+			connect.WithClientOptions(opts...),
+		),
+		renew: connect.NewClient[v1.RenewRequest, v1.RenewResponse](
+			httpClient,
+			baseURL+LockServiceRenewProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("Renew")),
+			connect.WithClientOptions(opts...),
+		),
+		release: connect.NewClient[v1.ReleaseRequest, v1.ReleaseResponse](
+			httpClient,
+			baseURL+LockServiceReleaseProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("Release")),
+			connect.WithClientOptions(opts...),
+		),
+		setAddress: connect.NewClient[v1.SetAddressRequest, v1.SetAddressResponse](
+			httpClient,
+			baseURL+LockServiceSetAddressProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("SetAddress")),
+			connect.WithClientOptions(opts...),
+		),
+		getLeader: connect.NewClient[v1.GetLeaderRequest, v1.GetLeaderResponse](
+			httpClient,
+			baseURL+LockServiceGetLeaderProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("GetLeader")),
+			connect.WithClientOptions(opts...),
+		),
+		history: connect.NewClient[v1.HistoryRequest, v1.HistoryResponse](
+			httpClient,
+			baseURL+LockServiceHistoryProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("History")),
+			connect.WithClientOptions(opts...),
+		),
+		watch: connect.NewClient[v1.WatchRequest, v1.WatchResponse](
+			httpClient,
+			baseURL+LockServiceWatchProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("Watch")),
+			connect.WithClientOptions(opts...),
+		),
+		keepAlive: connect.NewClient[v1.KeepAliveRequest, v1.KeepAliveResponse](
+			httpClient,
+			baseURL+LockServiceKeepAliveProcedure,
+			connect.WithSchema(lockServiceMethods.ByName("KeepAlive")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// lockServiceClient implements LockServiceClient.
+type lockServiceClient struct {
+	tryAcquire *connect.Client[v1.TryAcquireRequest, v1.TryAcquireResponse]
+	renew      *connect.Client[v1.RenewRequest, v1.RenewResponse]
+	release    *connect.Client[v1.ReleaseRequest, v1.ReleaseResponse]
+	setAddress *connect.Client[v1.SetAddressRequest, v1.SetAddressResponse]
+	getLeader  *connect.Client[v1.GetLeaderRequest, v1.GetLeaderResponse]
+	history    *connect.Client[v1.HistoryRequest, v1.HistoryResponse]
+	watch      *connect.Client[v1.WatchRequest, v1.WatchResponse]
+	keepAlive  *connect.Client[v1.KeepAliveRequest, v1.KeepAliveResponse]
+}
+
+// TryAcquire calls consensus.v1.LockService.TryAcquire.
+func (c *lockServiceClient) TryAcquire(ctx context.Context, req *connect.Request[v1.TryAcquireRequest]) (*connect.Response[v1.TryAcquireResponse], error) {
+	return c.tryAcquire.CallUnary(ctx, req)
+}
+
+// Renew calls consensus.v1.LockService.Renew.
+func (c *lockServiceClient) Renew(ctx context.Context, req *connect.Request[v1.RenewRequest]) (*connect.Response[v1.RenewResponse], error) {
+	return c.renew.CallUnary(ctx, req)
+}
+
+// Release calls consensus.v1.LockService.Release.
+func (c *lockServiceClient) Release(ctx context.Context, req *connect.Request[v1.ReleaseRequest]) (*connect.Response[v1.ReleaseResponse], error) {
+	return c.release.CallUnary(ctx, req)
+}
+
+// SetAddress calls consensus.v1.LockService.SetAddress.
+func (c *lockServiceClient) SetAddress(ctx context.Context, req *connect.Request[v1.SetAddressRequest]) (*connect.Response[v1.SetAddressResponse], error) {
+	return c.setAddress.CallUnary(ctx, req)
+}
+
+// GetLeader calls consensus.v1.LockService.GetLeader.
+func (c *lockServiceClient) GetLeader(ctx context.Context, req *connect.Request[v1.GetLeaderRequest]) (*connect.Response[v1.GetLeaderResponse], error) {
+	return c.getLeader.CallUnary(ctx, req)
+}
+
+// History calls consensus.v1.LockService.History.
+func (c *lockServiceClient) History(ctx context.Context, req *connect.Request[v1.HistoryRequest]) (*connect.Response[v1.HistoryResponse], error) {
+	return c.history.CallUnary(ctx, req)
+}
+
+// Watch calls consensus.v1.LockService.Watch.
+func (c *lockServiceClient) Watch(ctx context.Context, req *connect.Request[v1.WatchRequest]) (*connect.ServerStreamForClient[v1.WatchResponse], error) {
+	return c.watch.CallServerStream(ctx, req)
+}
+
+// KeepAlive calls consensus.v1.LockService.KeepAlive.
+func (c *lockServiceClient) KeepAlive(ctx context.Context, req *connect.Request[v1.KeepAliveRequest]) (*connect.ServerStreamForClient[v1.KeepAliveResponse], error) {
+	return c.keepAlive.CallServerStream(ctx, req)
+}
+
+// LockServiceHandler is an implementation of the consensus.v1.LockService service.
+type LockServiceHandler interface {
+	// TryAcquire attempts to acquire or renew leadership.
+	TryAcquire(context.Context, *connect.Request[v1.TryAcquireRequest]) (*connect.Response[v1.TryAcquireResponse], error)
+	// Renew extends the current leader's lease.
+	Renew(context.Context, *connect.Request[v1.RenewRequest]) (*connect.Response[v1.RenewResponse], error)
+	// Release explicitly gives up leadership.
+	Release(context.Context, *connect.Request[v1.ReleaseRequest]) (*connect.Response[v1.ReleaseResponse], error)
+	// SetAddress records the address at which the lease holder serves requests.
+	SetAddress(context.Context, *connect.Request[v1.SetAddressRequest]) (*connect.Response[v1.SetAddressResponse], error)
+	// GetLeader returns the current lease holder.
+	GetLeader(context.Context, *connect.Request[v1.GetLeaderRequest]) (*connect.Response[v1.GetLeaderResponse], error)
+	// History returns the recorded past tenures, oldest first.
+	History(context.Context, *connect.Request[v1.HistoryRequest]) (*connect.Response[v1.HistoryResponse], error)
+	// Watch streams the lease holder whenever it changes.
+	Watch(context.Context, *connect.Request[v1.WatchRequest], *connect.ServerStream[v1.WatchResponse]) error
+	// KeepAlive holds a client session open. When the stream ends and the
+	// client does not reconnect within the session TTL, the server releases
+	// every lease acquired through the session.
+	KeepAlive(context.Context, *connect.Request[v1.KeepAliveRequest], *connect.ServerStream[v1.KeepAliveResponse]) error
+}
+
+// NewLockServiceHandler builds an HTTP handler from the service implementation. It returns the path
+// on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewLockServiceHandler(svc LockServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	lockServiceMethods := v1.File_consensus_v1_consensus_proto.Services().ByName("LockService").Methods()
+	lockServiceTryAcquireHandler := connect.NewUnaryHandler(
+		LockServiceTryAcquireProcedure,
+		svc.TryAcquire,
+		connect.WithSchema(lockServiceMethods.ByName("TryAcquire")),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceRenewHandler := connect.NewUnaryHandler(
+		LockServiceRenewProcedure,
+		svc.Renew,
+		connect.WithSchema(lockServiceMethods.ByName("Renew")),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceReleaseHandler := connect.NewUnaryHandler(
+		LockServiceReleaseProcedure,
+		svc.Release,
+		connect.WithSchema(lockServiceMethods.ByName("Release")),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceSetAddressHandler := connect.NewUnaryHandler(
+		LockServiceSetAddressProcedure,
+		svc.SetAddress,
+		connect.WithSchema(lockServiceMethods.ByName("SetAddress")),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceGetLeaderHandler := connect.NewUnaryHandler(
+		LockServiceGetLeaderProcedure,
+		svc.GetLeader,
+		connect.WithSchema(lockServiceMethods.ByName("GetLeader")),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceHistoryHandler := connect.NewUnaryHandler(
+		LockServiceHistoryProcedure,
+		svc.History,
+		connect.WithSchema(lockServiceMethods.ByName("History")),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceWatchHandler := connect.NewServerStreamHandler(
+		LockServiceWatchProcedure,
+		svc.Watch,
+		connect.WithSchema(lockServiceMethods.ByName("Watch")),
+		connect.WithHandlerOptions(opts...),
+	)
+	lockServiceKeepAliveHandler := connect.NewServerStreamHandler(
+		LockServiceKeepAliveProcedure,
+		svc.KeepAlive,
+		connect.WithSchema(lockServiceMethods.ByName("KeepAlive")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/consensus.v1.LockService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case LockServiceTryAcquireProcedure:
+			lockServiceTryAcquireHandler.ServeHTTP(w, r)
+		case LockServiceRenewProcedure:
+			lockServiceRenewHandler.ServeHTTP(w, r)
+		case LockServiceReleaseProcedure:
+			lockServiceReleaseHandler.ServeHTTP(w, r)
+		case LockServiceSetAddressProcedure:
+			lockServiceSetAddressHandler.ServeHTTP(w, r)
+		case LockServiceGetLeaderProcedure:
+			lockServiceGetLeaderHandler.ServeHTTP(w, r)
+		case LockServiceHistoryProcedure:
+			lockServiceHistoryHandler.ServeHTTP(w, r)
+		case LockServiceWatchProcedure:
+			lockServiceWatchHandler.ServeHTTP(w, r)
+		case LockServiceKeepAliveProcedure:
+			lockServiceKeepAliveHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedLockServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedLockServiceHandler struct{}
+
+func (UnimplementedLockServiceHandler) TryAcquire(context.Context, *connect.Request[v1.TryAcquireRequest]) (*connect.Response[v1.TryAcquireResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.TryAcquire is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Renew(context.Context, *connect.Request[v1.RenewRequest]) (*connect.Response[v1.RenewResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.Renew is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Release(context.Context, *connect.Request[v1.ReleaseRequest]) (*connect.Response[v1.ReleaseResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.Release is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) SetAddress(context.Context, *connect.Request[v1.SetAddressRequest]) (*connect.Response[v1.SetAddressResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.SetAddress is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) GetLeader(context.Context, *connect.Request[v1.GetLeaderRequest]) (*connect.Response[v1.GetLeaderResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.GetLeader is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) History(context.Context, *connect.Request[v1.HistoryRequest]) (*connect.Response[v1.HistoryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.History is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) Watch(context.Context, *connect.Request[v1.WatchRequest], *connect.ServerStream[v1.WatchResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.Watch is not implemented"))
+}
+
+func (UnimplementedLockServiceHandler) KeepAlive(context.Context, *connect.Request[v1.KeepAliveRequest], *connect.ServerStream[v1.KeepAliveResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("consensus.v1.LockService.KeepAlive is not implemented"))
+}
@@ -2,9 +2,17 @@ package consensus
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrNotHolder indicates the caller does not hold the lease
+	ErrNotHolder = errors.New("not the lease holder")
+	// ErrNotLeader indicates an operation that requires leadership was attempted by a non-leader
+	ErrNotLeader = errors.New("not the leader")
+)
+
 // Backend abstracts the storage mechanism for leader election.
 type Backend interface {
 	// TryAcquire attempts to acquire or renew leadership.
@@ -14,9 +22,95 @@ type Backend interface {
 
 	// Renew extends the current leader's lease.
 	// leaseDuration specifies how long the lease is valid before expiring.
-	// Returns error if we're no longer the leader.
+	// Returns an error wrapping ErrNotHolder if identity does not hold the lease.
 	Renew(ctx context.Context, identity string, leaseDuration time.Duration) error
 
 	// Release explicitly gives up leadership.
+	// Releasing a lease that identity does not hold is a no-op and succeeds.
 	Release(ctx context.Context, identity string) error
 }
+
+// StateBackend is implemented by backends that can persist an opaque payload
+// alongside the lease record, such as a stream cursor or the last processed ID.
+// The payload survives failovers so a new leader can resume where the previous one stopped.
+type StateBackend interface {
+	// GetState returns the payload last written by a leader, or nil if none was written.
+	GetState(ctx context.Context) ([]byte, error)
+
+	// SetState stores the payload in the lease record.
+	// Returns an error wrapping ErrNotHolder if identity does not hold the lease.
+	SetState(ctx context.Context, identity string, state []byte) error
+}
+
+// LeaderRecord describes the current holder of a lease as stored by a backend.
+type LeaderRecord struct {
+	Identity      string        // Identity of the holder (the full holder ID as stored by backends)
+	Session       string        // Session ID of the holder; set by Manager.Leader, which splits it from Identity
+	Address       string        // Address advertised by the holder, empty if none
+	AcquireTime   time.Time     // When the holder acquired the lease
+	RenewTime     time.Time     // When the holder last renewed the lease
+	LeaseDuration time.Duration // How long the lease is valid after RenewTime
+	Transitions   uint64        // Number of times the lease has changed hands
+}
+
+// LeaderReader is implemented by backends that can report the current lease holder.
+type LeaderReader interface {
+	// GetLeader returns the current holder, or nil if the lease is free or expired.
+	GetLeader(ctx context.Context) (*LeaderRecord, error)
+}
+
+// AddressAdvertiser is implemented by backends that can store the holder's
+// advertised address with the lease so followers can reach the leader.
+type AddressAdvertiser interface {
+	// SetAddress records the address at which identity serves requests.
+	// Returns an error wrapping ErrNotHolder if identity does not hold the lease.
+	SetAddress(ctx context.Context, identity, address string) error
+}
+
+// Administrator is implemented by backends that support operator intervention
+// on a wedged lease, such as consensusctl's release and transfer commands.
+type Administrator interface {
+	// ForceRelease clears the lease regardless of who holds it.
+	ForceRelease(ctx context.Context) error
+
+	// Transfer hands the lease to identity. The previous holder's next renewal
+	// fails, and identity picks the lease up on its next acquire attempt.
+	// The lease expires as usual if identity does not renew it.
+	Transfer(ctx context.Context, identity string) error
+}
+
+// Claimer is implemented by backends that can claim a transferred lease
+// atomically. The Manager uses it to take over a lease an operator transferred
+// to its bare identity; without it, the claim falls back to Administrator.Transfer.
+type Claimer interface {
+	// Claim hands the lease from from to to, but only if from still holds it;
+	// otherwise it returns ErrNotHolder. The check and write are atomic, so
+	// only one of several candidates claiming from the same holder succeeds.
+	Claim(ctx context.Context, from, to string) error
+}
+
+// Watcher is implemented by backends that can push lease changes. The Manager
+// uses it to attempt acquisition as soon as the lease is released instead of
+// waiting for the next retry tick.
+type Watcher interface {
+	// Watch streams the lease holder whenever it changes, starting with the
+	// current one. A nil record means the lease is free. The channel is closed
+	// when ctx is cancelled or the watch fails.
+	Watch(ctx context.Context) (<-chan *LeaderRecord, error)
+}
+
+// CandidateQueue is implemented by backends that can keep a FIFO queue of
+// waiting candidates. With Config.FairQueue set, followers register in the
+// queue and only its head attempts acquisition, so leadership passes to the
+// longest-waiting candidate instead of whichever polls first.
+type CandidateQueue interface {
+	// Enqueue adds holder to the tail of the queue, or refreshes its entry if
+	// it is already waiting. Entries not refreshed within ttl expire.
+	Enqueue(ctx context.Context, holder string, ttl time.Duration) error
+
+	// Dequeue removes holder from the queue. Removing an absent holder is a no-op.
+	Dequeue(ctx context.Context, holder string) error
+
+	// QueueHead returns the longest-waiting live candidate, or "" if none is waiting.
+	QueueHead(ctx context.Context) (string, error)
+}
@@ -0,0 +1,397 @@
+// Package etcd implements consensus.Backend on an etcd key bound to an etcd lease.
+//
+// The holder's key is created in a transaction that requires it not to exist,
+// and is attached to an etcd lease whose TTL is the lease duration. Renew
+// keeps the etcd lease alive, so a holder that stops renewing loses the key
+// when etcd expires the lease. The key's create revision increases with every
+// acquisition and is reported as the fencing term. Past tenures are kept in
+// a companion "<key>.history" key that is not bound to any etcd lease.
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// record is the JSON value stored at the election key.
+type record struct {
+	Identity    string    `json:"identity"`
+	Address     string    `json:"address,omitempty"`
+	AcquireTime time.Time `json:"acquireTime"`
+}
+
+// historyRecord is the JSON value stored at the history key. Current is the
+// tenure in progress, so a successor can record it as expired if its holder
+// never released the key.
+type historyRecord struct {
+	Current *consensus.Tenure `json:"current,omitempty"`
+	History consensus.History `json:"history,omitempty"`
+}
+
+// holding is an etcd lease held by one identity through this Backend.
+type holding struct {
+	lease clientv3.LeaseID
+	ttl   int64
+	term  int64
+	value record
+}
+
+// Backend implements consensus.Backend using an etcd key.
+type Backend struct {
+	client       *clientv3.Client
+	key          string
+	historyLimit int
+
+	mu   sync.Mutex
+	held map[string]*holding
+}
+
+// Option configures a Backend.
+type Option func(*Backend)
+
+// WithHistoryLimit sets how many past tenures the history key keeps
+// (default: consensus.DefaultHistoryLimit).
+func WithHistoryLimit(n int) Option {
+	return func(b *Backend) {
+		b.historyLimit = n
+	}
+}
+
+// NewBackend creates a backend electing a leader through key.
+func NewBackend(client *clientv3.Client, key string, opts ...Option) *Backend {
+	b := &Backend{
+		client: client,
+		key:    key,
+		held:   make(map[string]*holding),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// TryAcquire attempts to acquire or renew leadership.
+func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	if b.holding(identity) != nil {
+		err := b.Renew(ctx, identity, leaseDuration)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, consensus.ErrNotHolder) {
+			return false, err
+		}
+	}
+
+	ttl := ttlSeconds(leaseDuration)
+	grant, err := b.client.Grant(ctx, ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to grant etcd lease: %w", err)
+	}
+
+	value := record{Identity: identity, AcquireTime: time.Now()}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := b.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(b.key), "=", 0)).
+		Then(clientv3.OpPut(b.key, string(raw), clientv3.WithLease(grant.ID))).
+		Commit()
+	if err != nil || !resp.Succeeded {
+		b.revoke(grant.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to acquire key: %w", err)
+		}
+		return false, nil
+	}
+
+	// The key's create revision is the revision of the transaction that created it
+	term := resp.Header.Revision
+	b.mu.Lock()
+	b.held[identity] = &holding{lease: grant.ID, ttl: ttl, term: term, value: value}
+	b.mu.Unlock()
+
+	b.updateHistory(ctx, func(rec *historyRecord) {
+		if rec.Current != nil {
+			// The previous holder's key expired without a release
+			rec.endCurrent(consensus.EndExpired, value.AcquireTime, identity, b.historyLimit)
+		}
+		rec.Current = &consensus.Tenure{
+			Identity:    identity,
+			Term:        uint64(term),
+			AcquireTime: value.AcquireTime,
+		}
+	})
+	return true, nil
+}
+
+// Renew extends the current leader's lease. A changed lease duration moves
+// the key to a new etcd lease with the new TTL, keeping its create revision.
+func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
+	h := b.holding(identity)
+	if h == nil {
+		return consensus.ErrNotHolder
+	}
+
+	ttl := ttlSeconds(leaseDuration)
+	if ttl != h.ttl {
+		return b.rebind(ctx, identity, h, ttl)
+	}
+
+	if _, err := b.client.KeepAliveOnce(ctx, h.lease); err != nil {
+		if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+			b.forget(identity, h)
+			return consensus.ErrNotHolder
+		}
+		return fmt.Errorf("failed to keep etcd lease alive: %w", err)
+	}
+
+	// The key may have been deleted (e.g. by an operator) while the lease lives on
+	resp, err := b.client.Get(ctx, b.key)
+	if err != nil {
+		return fmt.Errorf("failed to read key: %w", err)
+	}
+	if len(resp.Kvs) == 0 || clientv3.LeaseID(resp.Kvs[0].Lease) != h.lease {
+		b.forget(identity, h)
+		b.revoke(h.lease)
+		return consensus.ErrNotHolder
+	}
+
+	return nil
+}
+
+// Release explicitly gives up leadership.
+func (b *Backend) Release(ctx context.Context, identity string) error {
+	h := b.holding(identity)
+	if h == nil {
+		return nil
+	}
+
+	resp, err := b.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.LeaseValue(b.key), "=", h.lease)).
+		Then(clientv3.OpDelete(b.key)).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to release key: %w", err)
+	}
+
+	b.forget(identity, h)
+	b.revoke(h.lease)
+	if resp.Succeeded {
+		b.updateHistory(ctx, func(rec *historyRecord) {
+			if rec.Current != nil && rec.Current.Term == uint64(h.term) {
+				rec.endCurrent(consensus.EndReleased, time.Now(), "", b.historyLimit)
+			}
+		})
+	}
+	return nil
+}
+
+// History returns the past tenures recorded in the history key, oldest first.
+// A holder whose key expired is recorded once a successor acquires the key,
+// with the successor's acquire time as its end time.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	rec, _, err := b.readHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rec.History, nil
+}
+
+// GetLeader returns the current key holder, or nil if the key does not exist.
+// Transitions is the key's create revision.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	resp, err := b.client.Get(ctx, b.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, nil
+	}
+
+	kv := resp.Kvs[0]
+	var value record
+	if err := json.Unmarshal(kv.Value, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode key %s: %w", b.key, err)
+	}
+
+	leader := &consensus.LeaderRecord{
+		Identity:    value.Identity,
+		Address:     value.Address,
+		AcquireTime: value.AcquireTime,
+		RenewTime:   time.Now(),
+		Transitions: uint64(kv.CreateRevision),
+	}
+
+	// Derive the last renewal from the remaining TTL
+	ttl, err := b.client.TimeToLive(ctx, clientv3.LeaseID(kv.Lease))
+	if err == nil && ttl.GrantedTTL > 0 {
+		leader.LeaseDuration = time.Duration(ttl.GrantedTTL) * time.Second
+		leader.RenewTime = time.Now().Add(-time.Duration(ttl.GrantedTTL-ttl.TTL) * time.Second)
+	}
+
+	return leader, nil
+}
+
+// SetAddress records the address at which identity can be reached.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	h := b.holding(identity)
+	if h == nil {
+		return consensus.ErrNotHolder
+	}
+
+	value := h.value
+	value.Address = address
+	if err := b.put(ctx, h.lease, h.lease, value); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	h.value = value
+	b.mu.Unlock()
+	return nil
+}
+
+// rebind moves the key to a new etcd lease with ttl.
+func (b *Backend) rebind(ctx context.Context, identity string, h *holding, ttl int64) error {
+	grant, err := b.client.Grant(ctx, ttl)
+	if err != nil {
+		return fmt.Errorf("failed to grant etcd lease: %w", err)
+	}
+
+	if err := b.put(ctx, h.lease, grant.ID, h.value); err != nil {
+		b.revoke(grant.ID)
+		if errors.Is(err, consensus.ErrNotHolder) {
+			b.forget(identity, h)
+			b.revoke(h.lease)
+		}
+		return err
+	}
+
+	b.mu.Lock()
+	b.held[identity] = &holding{lease: grant.ID, ttl: ttl, term: h.term, value: h.value}
+	b.mu.Unlock()
+	b.revoke(h.lease)
+	return nil
+}
+
+// put writes value under lease next if the key is still bound to lease current.
+func (b *Backend) put(ctx context.Context, current, next clientv3.LeaseID, value record) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.LeaseValue(b.key), "=", current)).
+		Then(clientv3.OpPut(b.key, string(raw), clientv3.WithLease(next))).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("failed to update key: %w", err)
+	}
+	if !resp.Succeeded {
+		return consensus.ErrNotHolder
+	}
+	return nil
+}
+
+// updateHistory applies fn to the history key with a revision-checked write,
+// retrying if another candidate wrote it concurrently. It runs after the
+// change it records and a failure is not reported: the transition has
+// already happened.
+func (b *Backend) updateHistory(ctx context.Context, fn func(*historyRecord)) {
+	for range 5 {
+		rec, revision, err := b.readHistory(ctx)
+		if err != nil {
+			// Undecodable history is replaced rather than blocking new records
+			rec = &historyRecord{}
+		}
+
+		fn(rec)
+		raw, err := json.Marshal(rec)
+		if err != nil {
+			return
+		}
+		resp, err := b.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(b.historyKey()), "=", revision)).
+			Then(clientv3.OpPut(b.historyKey(), string(raw))).
+			Commit()
+		if err != nil || resp.Succeeded {
+			return
+		}
+	}
+}
+
+// readHistory returns the history record and its mod revision, which is
+// zero if the key does not exist.
+func (b *Backend) readHistory(ctx context.Context) (*historyRecord, int64, error) {
+	resp, err := b.client.Get(ctx, b.historyKey())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read history key: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return &historyRecord{}, 0, nil
+	}
+
+	kv := resp.Kvs[0]
+	var rec historyRecord
+	if err := json.Unmarshal(kv.Value, &rec); err != nil {
+		return &historyRecord{}, kv.ModRevision, fmt.Errorf("failed to decode history key: %w", err)
+	}
+	return &rec, kv.ModRevision, nil
+}
+
+// historyKey returns the key holding past tenures.
+func (b *Backend) historyKey() string {
+	return b.key + ".history"
+}
+
+// endCurrent moves the tenure in progress to the history.
+func (r *historyRecord) endCurrent(reason consensus.EndReason, end time.Time, successor string, limit int) {
+	tenure := *r.Current
+	tenure.EndTime = end
+	tenure.Reason = reason
+	tenure.Successor = successor
+	r.History = r.History.Append(tenure, limit)
+	r.Current = nil
+}
+
+// holding returns identity's etcd lease, or nil if it holds none through this Backend.
+func (b *Backend) holding(identity string) *holding {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.held[identity]
+}
+
+// forget drops identity's etcd lease if it is still h.
+func (b *Backend) forget(identity string, h *holding) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.held[identity] == h {
+		delete(b.held, identity)
+	}
+}
+
+// revoke revokes an etcd lease with its own timeout, so cleanup still
+// happens when the caller's context is cancelled.
+func (b *Backend) revoke(id clientv3.LeaseID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = b.client.Revoke(ctx, id)
+}
+
+// ttlSeconds converts a lease duration to a whole-second etcd TTL, rounding up.
+func ttlSeconds(d time.Duration) int64 {
+	return max(1, int64(math.Ceil(d.Seconds())))
+}
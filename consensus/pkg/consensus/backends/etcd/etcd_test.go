@@ -0,0 +1,132 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+)
+
+// startEtcd runs a single-member etcd server in-process and returns a client for it.
+func startEtcd(t *testing.T) *clientv3.Client {
+	t.Helper()
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	// Short ticks lower etcd's minimum lease TTL below the suite's one-second leases
+	cfg.TickMs = 10
+	cfg.ElectionMs = 100
+
+	clientURL := freeURL(t)
+	peerURL := freeURL(t)
+	cfg.ListenClientUrls = []url.URL{clientURL}
+	cfg.AdvertiseClientUrls = []url.URL{clientURL}
+	cfg.ListenPeerUrls = []url.URL{peerURL}
+	cfg.AdvertisePeerUrls = []url.URL{peerURL}
+	cfg.InitialCluster = fmt.Sprintf("%s=%s", cfg.Name, peerURL.String())
+
+	server, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	select {
+	case <-server.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		t.Fatal("etcd did not become ready")
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   []string{clientURL.String()},
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// freeURL returns a loopback URL on a currently unused port.
+func freeURL(t *testing.T) url.URL {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return url.URL{Scheme: "http", Host: l.Addr().String()}
+}
+
+func TestBackendConformance(t *testing.T) {
+	client := startEtcd(t)
+	var keys atomic.Int64
+
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		return NewBackend(client, fmt.Sprintf("/consensus/test-%d", keys.Add(1)))
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	client := startEtcd(t)
+	var keys atomic.Int64
+
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		return NewBackend(client, fmt.Sprintf("/consensus/history-%d", keys.Add(1)))
+	})
+}
+
+func TestTermIsCreateRevision(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend(startEtcd(t), "/consensus/term")
+
+	if ok, err := b.TryAcquire(ctx, "a", 10*time.Second); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	first, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.SetAddress(ctx, "a", "http://a:8080"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Renew(ctx, "a", 20*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.Transitions != first.Transitions || renewed.Address != "http://a:8080" {
+		t.Fatalf("after renew: term %d address %q, want term %d", renewed.Transitions, renewed.Address, first.Transitions)
+	}
+	if renewed.LeaseDuration != 20*time.Second {
+		t.Fatalf("lease duration = %v, want 20s", renewed.LeaseDuration)
+	}
+
+	if err := b.Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.TryAcquire(ctx, "b", 10*time.Second); err != nil || !ok {
+		t.Fatalf("takeover: ok=%v err=%v", ok, err)
+	}
+	second, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Identity != "b" || second.Transitions <= first.Transitions {
+		t.Fatalf("new leader %q term %d, want b with term > %d", second.Identity, second.Transitions, first.Transitions)
+	}
+}
@@ -3,35 +3,151 @@ package file
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"syscall"
+	"path/filepath"
+	"strconv"
 	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
 )
 
+// formatVersion is the lease file schema version written by this package.
+// Files with a higher version are rejected rather than silently misread.
+const formatVersion = 1
+
+// DefaultCorruptionGracePeriod is how long an undecodable lease file is
+// respected before it is treated as an expired lease.
+const DefaultCorruptionGracePeriod = time.Minute
+
 // leaseData represents the JSON structure stored in the lease file.
 type leaseData struct {
-	Holder        string        `json:"holder"`
-	RenewTime     time.Time     `json:"renewTime"`
-	LeaseDuration time.Duration `json:"leaseDuration"`
+	Version       int               `json:"version"`
+	Holder        string            `json:"holder"`
+	Address       string            `json:"address,omitempty"`
+	AcquireTime   time.Time         `json:"acquireTime"`
+	RenewTime     time.Time         `json:"renewTime"`
+	LeaseDuration time.Duration     `json:"leaseDuration"`
+	Transitions   uint64            `json:"transitions"`
+	State         []byte            `json:"state,omitempty"`
+	Queue         consensus.Queue   `json:"queue,omitempty"`
+	History       consensus.History `json:"history,omitempty"`
+}
+
+// expired reports whether the lease is free or has lapsed at now.
+func (d *leaseData) expired(now time.Time) bool {
+	return d.Holder == "" || now.Sub(d.RenewTime) > d.LeaseDuration
 }
 
 // Backend implements consensus.Backend using a file-based lock.
 type Backend struct {
-	path string
+	path            string
+	corruptionGrace time.Duration
+	lockMode        LockMode
+	staleLock       time.Duration
+	ttl             time.Duration
+	historyLimit    int
+}
+
+// Option configures a Backend.
+type Option func(*Backend)
+
+// WithCorruptionGracePeriod sets how long an undecodable lease file is
+// respected before it is treated as expired (default: DefaultCorruptionGracePeriod).
+// It should be longer than the lease duration in use.
+func WithCorruptionGracePeriod(d time.Duration) Option {
+	return func(b *Backend) {
+		b.corruptionGrace = d
+	}
+}
+
+// WithLockMode selects how read-modify-write cycles are serialized (default: LockFlock).
+// Use LockExclusiveCreate when the lease file is shared across hosts on NFS/EFS.
+func WithLockMode(mode LockMode) Option {
+	return func(b *Backend) {
+		b.lockMode = mode
+	}
+}
+
+// WithStaleLockTimeout sets how old an exclusive-create lock file must be
+// before another process breaks it (default: DefaultStaleLockTimeout).
+// Only used with LockExclusiveCreate.
+func WithStaleLockTimeout(d time.Duration) Option {
+	return func(b *Backend) {
+		b.staleLock = d
+	}
+}
+
+// WithTTL fixes the lease duration, overriding the one requested by the
+// Manager. Keep it longer than the Manager's RenewInterval.
+func WithTTL(ttl time.Duration) Option {
+	return func(b *Backend) {
+		b.ttl = ttl
+	}
+}
+
+// WithHistoryLimit sets how many past tenures the lease file keeps
+// (default: consensus.DefaultHistoryLimit).
+func WithHistoryLimit(n int) Option {
+	return func(b *Backend) {
+		b.historyLimit = n
+	}
 }
 
 // NewBackend creates a new file-based backend.
-func NewBackend(path string) *Backend {
-	return &Backend{
-		path: path,
+// The lease is stored at path and guarded by a lock file next to it.
+func NewBackend(path string, opts ...Option) *Backend {
+	b := &Backend{
+		path:            path,
+		corruptionGrace: DefaultCorruptionGracePeriod,
+		lockMode:        LockFlock,
+		staleLock:       DefaultStaleLockTimeout,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// NewFromEnv creates a File backend from environment variables.
+// path: filesystem path to the lease file; its parent directory is created if needed
+// Environment variables:
+//
+//	CONSENSUS_FILE_TTL - lease duration in whole seconds (default: "15")
+//
+// Errors wrap consensus.ErrInvalidConfig for an unparseable or non-positive
+// TTL, or report that the directory cannot be created or written.
+func NewFromEnv(path string) (*Backend, error) {
+	ttl := 15 * time.Second
+	if raw := os.Getenv("CONSENSUS_FILE_TTL"); raw != "" {
+		secs, err := strconv.Atoi(raw)
+		if err != nil || secs <= 0 {
+			return nil, fmt.Errorf("%w: CONSENSUS_FILE_TTL must be a positive number of seconds, got %q", consensus.ErrInvalidConfig, raw)
+		}
+		ttl = time.Duration(secs) * time.Second
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create lease directory: %w", err)
+	}
+
+	probe, err := os.CreateTemp(dir, ".consensus-probe-*")
+	if err != nil {
+		return nil, fmt.Errorf("lease directory not writable: %w", err)
 	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return NewBackend(path, WithTTL(ttl)), nil
 }
 
 // TryAcquire attempts to acquire or renew leadership.
 func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
-	return b.withLock(func(file *os.File) (bool, error) {
-		data, err := b.readLease(file)
+	leaseDuration = b.leaseDuration(leaseDuration)
+	return b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
 		if err != nil {
 			return false, err
 		}
@@ -42,18 +158,22 @@ func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration
 		if data.Holder == identity {
 			data.RenewTime = now
 			data.LeaseDuration = leaseDuration
-			if err := b.writeLease(file, data); err != nil {
+			if err := b.writeLease(data); err != nil {
 				return false, err
 			}
 			return true, nil
 		}
 
 		// If no holder or lease expired, acquire
-		if data.Holder == "" || now.Sub(data.RenewTime) > data.LeaseDuration {
+		if data.expired(now) {
+			b.endTenure(data, consensus.EndExpired, now, identity)
+			data.Transitions++
 			data.Holder = identity
+			data.Address = ""
+			data.AcquireTime = now
 			data.RenewTime = now
 			data.LeaseDuration = leaseDuration
-			if err := b.writeLease(file, data); err != nil {
+			if err := b.writeLease(data); err != nil {
 				return false, err
 			}
 			return true, nil
@@ -66,21 +186,22 @@ func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration
 
 // Renew extends the current leader's lease.
 func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
-	acquired, err := b.withLock(func(file *os.File) (bool, error) {
-		data, err := b.readLease(file)
+	leaseDuration = b.leaseDuration(leaseDuration)
+	acquired, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
 		if err != nil {
 			return false, err
 		}
 
 		// Verify we're the holder
 		if data.Holder != identity {
-			return false, fmt.Errorf("not the lease holder")
+			return false, consensus.ErrNotHolder
 		}
 
 		// Update renewal time and duration
 		data.RenewTime = time.Now()
 		data.LeaseDuration = leaseDuration
-		if err := b.writeLease(file, data); err != nil {
+		if err := b.writeLease(data); err != nil {
 			return false, err
 		}
 
@@ -99,16 +220,18 @@ func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time
 
 // Release explicitly gives up leadership.
 func (b *Backend) Release(ctx context.Context, identity string) error {
-	_, err := b.withLock(func(file *os.File) (bool, error) {
-		data, err := b.readLease(file)
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
 		if err != nil {
 			return false, err
 		}
 
 		// Only release if we're the holder
 		if data.Holder == identity {
+			b.endTenure(data, consensus.EndReleased, time.Now(), "")
 			data.Holder = ""
-			if err := b.writeLease(file, data); err != nil {
+			data.Address = ""
+			if err := b.writeLease(data); err != nil {
 				return false, err
 			}
 		}
@@ -119,75 +242,382 @@ func (b *Backend) Release(ctx context.Context, identity string) error {
 	return err
 }
 
-// withLock executes a function while holding an exclusive file lock.
-func (b *Backend) withLock(fn func(*os.File) (bool, error)) (bool, error) {
-	// Open or create the file
-	file, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE, 0644)
+// GetState returns the checkpoint payload stored in the lease file.
+func (b *Backend) GetState(ctx context.Context) ([]byte, error) {
+	var state []byte
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+		state = data.State
+		return true, nil
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to open lease file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	// Acquire exclusive lock
-	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
-		return false, fmt.Errorf("failed to acquire file lock: %w", err)
+	return state, nil
+}
+
+// SetState stores a checkpoint payload in the lease file.
+// The write is rejected unless identity currently holds the lease.
+func (b *Backend) SetState(ctx context.Context, identity string, state []byte) error {
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+
+		// Verify we're the holder
+		if data.Holder != identity {
+			return false, consensus.ErrNotHolder
+		}
+
+		data.State = state
+		if err := b.writeLease(data); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	return err
+}
+
+// GetLeader returns the current lease holder, or nil if the lease is free or expired.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	var record *consensus.LeaderRecord
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+		if data.expired(time.Now()) {
+			return false, nil
+		}
+
+		record = &consensus.LeaderRecord{
+			Identity:      data.Holder,
+			Address:       data.Address,
+			AcquireTime:   data.AcquireTime,
+			RenewTime:     data.RenewTime,
+			LeaseDuration: data.LeaseDuration,
+			Transitions:   data.Transitions,
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
 
-	return fn(file)
+	return record, nil
 }
 
-// readLease reads the lease data from the file.
-func (b *Backend) readLease(file *os.File) (*leaseData, error) {
-	// Seek to beginning
-	if _, err := file.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("failed to seek: %w", err)
+// SetAddress records the holder's advertised address in the lease file.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+
+		// Verify we're the holder
+		if data.Holder != identity {
+			return false, consensus.ErrNotHolder
+		}
+
+		data.Address = address
+		if err := b.writeLease(data); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	return err
+}
+
+// ForceRelease clears the lease regardless of who holds it.
+func (b *Backend) ForceRelease(ctx context.Context) error {
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+
+		b.endTenure(data, consensus.EndPreempted, time.Now(), "")
+		data.Holder = ""
+		data.Address = ""
+		if err := b.writeLease(data); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	return err
+}
+
+// Transfer hands the lease to identity, starting a fresh lease term.
+func (b *Backend) Transfer(ctx context.Context, identity string) error {
+	return b.transfer(ctx, identity, nil)
+}
+
+// Claim hands the lease from from to to if from still holds it, continuing
+// from's tenure. It returns consensus.ErrNotHolder otherwise.
+func (b *Backend) Claim(ctx context.Context, from, to string) error {
+	return b.transfer(ctx, to, func(holder string) bool { return holder == from })
+}
+
+// transfer hands the lease to identity under the lock, if allowed accepts
+// the current holder or is nil.
+func (b *Backend) transfer(ctx context.Context, identity string, allowed func(holder string) bool) error {
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+		if allowed != nil && !allowed(data.Holder) {
+			return false, consensus.ErrNotHolder
+		}
+
+		now := time.Now()
+		if data.Holder != identity {
+			// A Manager claiming a transfer to its bare identity continues that tenure
+			if claimed, _ := consensus.SplitHolder(identity); claimed != data.Holder {
+				b.endTenure(data, consensus.EndTransferred, now, identity)
+			}
+			data.Transitions++
+		}
+		data.Holder = identity
+		data.Address = ""
+		data.AcquireTime = now
+		data.RenewTime = now
+		if err := b.writeLease(data); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	return err
+}
+
+// Enqueue adds holder to the candidate queue kept in the lease file, or refreshes its entry.
+func (b *Backend) Enqueue(ctx context.Context, holder string, ttl time.Duration) error {
+	return b.updateQueue(ctx, func(q consensus.Queue) (consensus.Queue, bool) {
+		return q.Enqueue(holder, ttl, time.Now())
+	})
+}
+
+// Dequeue removes holder from the candidate queue.
+func (b *Backend) Dequeue(ctx context.Context, holder string) error {
+	return b.updateQueue(ctx, func(q consensus.Queue) (consensus.Queue, bool) {
+		return q.Remove(holder)
+	})
+}
+
+// QueueHead returns the longest-waiting live candidate, or "" if none is waiting.
+func (b *Backend) QueueHead(ctx context.Context) (string, error) {
+	var head string
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+		head = data.Queue.Head(time.Now())
+		return true, nil
+	})
+
+	return head, err
+}
+
+// updateQueue applies fn to the candidate queue, writing the file only if it changed.
+func (b *Backend) updateQueue(ctx context.Context, fn func(consensus.Queue) (consensus.Queue, bool)) error {
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+
+		queue, changed := fn(data.Queue)
+		if !changed {
+			return true, nil
+		}
+		data.Queue = queue
+		if err := b.writeLease(data); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	return err
+}
+
+// History returns the past tenures recorded in the lease file, oldest first.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	var history []consensus.Tenure
+	_, err := b.withLock(ctx, func() (bool, error) {
+		data, err := b.readLease()
+		if err != nil {
+			return false, err
+		}
+		history = data.History
+		return true, nil
+	})
+
+	return history, err
+}
+
+// endTenure appends the current holder's tenure to the history. A lease that
+// had already lapsed is recorded as expired at its expiry, whatever ended it.
+func (b *Backend) endTenure(data *leaseData, reason consensus.EndReason, now time.Time, successor string) {
+	if data.Holder == "" {
+		return
 	}
 
-	// Check if file is empty
-	stat, err := file.Stat()
+	end := now
+	if data.expired(now) {
+		reason = consensus.EndExpired
+		end = data.RenewTime.Add(data.LeaseDuration)
+	}
+	data.History = data.History.Append(consensus.Tenure{
+		Identity:    data.Holder,
+		Term:        data.Transitions,
+		AcquireTime: data.AcquireTime,
+		EndTime:     end,
+		Reason:      reason,
+		Successor:   successor,
+	}, b.historyLimit)
+}
+
+// leaseDuration returns the configured TTL, or requested if none is set.
+func (b *Backend) leaseDuration(requested time.Duration) time.Duration {
+	if b.ttl > 0 {
+		return b.ttl
+	}
+	return requested
+}
+
+// readLease reads the lease data from the file.
+// A missing or empty file is an empty lease. A file that cannot be decoded
+// (e.g. left behind by a crash on a filesystem without atomic rename) is
+// recovered from its backup once it is older than the corruption grace period.
+func (b *Backend) readLease() (*leaseData, error) {
+	raw, err := os.ReadFile(b.path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
+		if errors.Is(err, os.ErrNotExist) {
+			return &leaseData{}, nil
+		}
+		return nil, fmt.Errorf("failed to read lease: %w", err)
 	}
 
-	if stat.Size() == 0 {
+	if len(raw) == 0 {
 		// Empty file - return empty lease
 		return &leaseData{}, nil
 	}
 
-	// Read and parse JSON
+	// Parse JSON
 	var data leaseData
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&data); err != nil {
+	if err := json.Unmarshal(raw, &data); err != nil {
+		stat, statErr := os.Stat(b.path)
+		if statErr == nil && time.Since(stat.ModTime()) > b.corruptionGrace {
+			return b.recoverLease(), nil
+		}
 		return nil, fmt.Errorf("failed to decode lease: %w", err)
 	}
 
+	if data.Version > formatVersion {
+		return nil, fmt.Errorf("unsupported lease format version %d (max %d)", data.Version, formatVersion)
+	}
+
 	return &data, nil
 }
 
-// writeLease writes the lease data to the file.
-func (b *Backend) writeLease(file *os.File, data *leaseData) error {
-	// Seek to beginning
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek: %w", err)
+// recoverLease returns the last good lease from the backup beside a corrupt
+// lease file. Its holder has long lapsed, since the grace period outlives the
+// lease, so the next candidate takes over and records it as expired. The term
+// is advanced past any the lost write may have handed out, so fencing terms
+// never go backwards. Without a usable backup the lease starts empty.
+func (b *Backend) recoverLease() *leaseData {
+	raw, err := os.ReadFile(b.backupPath())
+	if err != nil {
+		return &leaseData{}
 	}
 
-	// Truncate file
-	if err := file.Truncate(0); err != nil {
-		return fmt.Errorf("failed to truncate: %w", err)
+	var data leaseData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Version > formatVersion {
+		return &leaseData{}
 	}
+	data.Transitions++
+	return &data
+}
+
+// writeLease writes the lease data to the file atomically, then to its
+// backup. A crash leaves at least one of the two decodable.
+func (b *Backend) writeLease(data *leaseData) error {
+	data.Version = formatVersion
 
-	// Write JSON
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(data); err != nil {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
 		return fmt.Errorf("failed to encode lease: %w", err)
 	}
+	raw = append(raw, '\n')
+
+	if err := writeAtomic(b.path, raw); err != nil {
+		return err
+	}
+	return writeAtomic(b.backupPath(), raw)
+}
+
+// backupPath returns the path of the last good copy of the lease file.
+func (b *Backend) backupPath() string {
+	return b.path + ".bak"
+}
 
-	// Sync to disk
-	if err := file.Sync(); err != nil {
+// writeAtomic replaces the file at path with raw.
+// The data is written to a temporary file in the same directory, synced, and
+// renamed over path, so readers see either the old or the new contents.
+func writeAtomic(path string, raw []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op after a successful rename
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+
+	// Sync to disk before the rename makes the data visible
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
 		return fmt.Errorf("failed to sync: %w", err)
 	}
 
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to chmod temp file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+
+	// Sync the directory so the rename itself survives a crash
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+
 	return nil
 }
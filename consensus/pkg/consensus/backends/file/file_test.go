@@ -0,0 +1,471 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+)
+
+func TestCorruptLeaseRespectedDuringGracePeriod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	if err := os.WriteFile(path, []byte(`{"holder": "a", "renewT`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBackend(path, WithCorruptionGracePeriod(time.Hour))
+	if _, err := b.TryAcquire(context.Background(), "b", time.Second); err == nil {
+		t.Fatal("expected decode error while corrupt file is within grace period")
+	}
+}
+
+func TestCorruptLeaseRecoveredAfterGracePeriod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	if err := os.WriteFile(path, []byte(`{"holder": "a", "renewT`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBackend(path, WithCorruptionGracePeriod(time.Minute))
+	acquired, err := b.TryAcquire(context.Background(), "b", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acquired {
+		t.Fatal("expected to acquire after grace period")
+	}
+
+	// The file is rewritten as valid, versioned JSON
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data leaseData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("lease file not valid JSON after recovery: %v", err)
+	}
+	if data.Holder != "b" || data.Version != formatVersion {
+		t.Fatalf("got holder %q version %d", data.Holder, data.Version)
+	}
+}
+
+func TestCorruptLeaseRecoveryKeepsTermAndHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	ctx := context.Background()
+	b := NewBackend(path, WithCorruptionGracePeriod(time.Minute))
+
+	for _, identity := range []string{"a", "b"} {
+		if ok, err := b.TryAcquire(ctx, identity, time.Second); err != nil || !ok {
+			t.Fatalf("acquire %s: ok=%v err=%v", identity, ok, err)
+		}
+		if err := b.Release(ctx, identity); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, err := b.TryAcquire(ctx, "c", 10*time.Millisecond); err != nil || !ok {
+		t.Fatalf("acquire c: ok=%v err=%v", ok, err)
+	}
+	before, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash corrupts the lease file, and nobody can decode it until the
+	// grace period, which outlives c's lease, has passed
+	time.Sleep(20 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(`{"holder": "c", "renewT`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := b.TryAcquire(ctx, "d", time.Second); err != nil || !ok {
+		t.Fatalf("acquire after recovery: ok=%v err=%v", ok, err)
+	}
+	after, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after == nil || after.Identity != "d" || after.Transitions <= before.Transitions {
+		t.Fatalf("leader = %+v, want d with a term above %d", after, before.Transitions)
+	}
+
+	history, err := b.History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history = %+v, want a, b and c", history)
+	}
+	for i, want := range []string{"a", "b", "c"} {
+		if history[i].Identity != want {
+			t.Fatalf("tenure %d = %+v, want %s", i, history[i], want)
+		}
+	}
+	if history[2].Reason != consensus.EndExpired || history[2].Successor != "d" {
+		t.Fatalf("tenure = %+v, want c expired with successor d", history[2])
+	}
+}
+
+func TestWriteLeavesNoTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	b := NewBackend(filepath.Join(dir, "lease.json"))
+
+	for range 3 {
+		if _, err := b.TryAcquire(context.Background(), "a", time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "lease.json" && e.Name() != "lease.json.bak" && e.Name() != "lease.json.lock" {
+			t.Errorf("unexpected file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestNewerFormatVersionRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "holder": "a"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBackend(path)
+	if _, err := b.TryAcquire(context.Background(), "b", time.Second); err == nil {
+		t.Fatal("expected error for unsupported format version")
+	}
+}
+
+func TestExclusiveCreateLockSerializesAcquires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+
+	results := make(chan bool, 20)
+	for i := range 20 {
+		go func() {
+			b := NewBackend(path, WithLockMode(LockExclusiveCreate))
+			acquired, err := b.TryAcquire(context.Background(), fmt.Sprintf("instance-%d", i), time.Minute)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- acquired
+		}()
+	}
+
+	winners := 0
+	for range 20 {
+		if <-results {
+			winners++
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("%d instances acquired the lease, want 1", winners)
+	}
+	if _, err := os.Stat(path + ".lck"); !os.IsNotExist(err) {
+		t.Fatalf("lock file not removed after use: %v", err)
+	}
+}
+
+func TestExclusiveCreateLockBreaksStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	stale := fmt.Sprintf(`{"host": "gone", "pid": 1, "nonce": "x", "created": %q}`, time.Now().Add(-time.Hour).Format(time.RFC3339Nano))
+	if err := os.WriteFile(path+".lck", []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	b := NewBackend(path, WithLockMode(LockExclusiveCreate), WithStaleLockTimeout(time.Minute))
+	acquired, err := b.TryAcquire(ctx, "a", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acquired {
+		t.Fatal("expected to acquire after breaking stale lock")
+	}
+}
+
+func TestExclusiveCreateLockBrokenByOneProcess(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lease.json")
+	stale := fmt.Sprintf(`{"host": "gone", "pid": 1, "nonce": "x", "created": %q}`, time.Now().Add(-time.Hour).Format(time.RFC3339Nano))
+	if err := os.WriteFile(path+".lck", []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every candidate finds the same stale lock; only one may get the lease
+	results := make(chan bool, 20)
+	for i := range 20 {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			b := NewBackend(path, WithLockMode(LockExclusiveCreate), WithStaleLockTimeout(time.Minute))
+			acquired, err := b.TryAcquire(ctx, fmt.Sprintf("instance-%d", i), time.Minute)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- acquired
+		}()
+	}
+
+	winners := 0
+	for range 20 {
+		if <-results {
+			winners++
+		}
+	}
+	if winners != 1 {
+		t.Fatalf("%d instances acquired the lease, want 1", winners)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "lease.json" && e.Name() != "lease.json.bak" {
+			t.Errorf("unexpected file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestExclusiveCreateLockClearsStaleBreaker(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lease.json")
+	stale := fmt.Sprintf(`{"host": "gone", "pid": 1, "nonce": "x", "created": %q}`, time.Now().Add(-time.Hour).Format(time.RFC3339Nano))
+	// A breaker that crashed mid-break left both the stale lock and its marker
+	for _, name := range []string{".lck", ".lck.break"} {
+		if err := os.WriteFile(path+name, []byte(stale), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	b := NewBackend(path, WithLockMode(LockExclusiveCreate), WithStaleLockTimeout(time.Minute))
+	if acquired, err := b.TryAcquire(ctx, "a", time.Second); err != nil || !acquired {
+		t.Fatalf("acquire: acquired=%v err=%v", acquired, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "lease.json" && e.Name() != "lease.json.bak" {
+			t.Errorf("unexpected file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestExclusiveCreateLockReleaseSparesPeerLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	peer := fmt.Sprintf(`{"host": "peer", "pid": 1, "nonce": "peer", "created": %q}`, time.Now().Format(time.RFC3339Nano))
+
+	// Our lock is broken as stale and taken over by a peer while we hold it
+	b := NewBackend(path, WithLockMode(LockExclusiveCreate))
+	_, err := b.withLock(context.Background(), func() (bool, error) {
+		return true, os.WriteFile(path+".lck", []byte(peer), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nonce := ownerNonce(path + ".lck"); nonce != "peer" {
+		t.Fatalf("lock owner after release = %q, want the peer's lock left in place", nonce)
+	}
+}
+
+func TestExclusiveCreateLockWaitsForLiveLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	live := fmt.Sprintf(`{"host": "other", "pid": 1, "nonce": "x", "created": %q}`, time.Now().Format(time.RFC3339Nano))
+	if err := os.WriteFile(path+".lck", []byte(live), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	b := NewBackend(path, WithLockMode(LockExclusiveCreate), WithStaleLockTimeout(time.Minute))
+	if _, err := b.TryAcquire(ctx, "a", time.Second); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want deadline exceeded while lock is held", err)
+	}
+}
+
+func TestNewFromEnvAppliesTTL(t *testing.T) {
+	t.Setenv("CONSENSUS_FILE_TTL", "42")
+	path := filepath.Join(t.TempDir(), "nested", "lease.json")
+
+	b, err := NewFromEnv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if ok, err := b.TryAcquire(ctx, "a", time.Second); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader.LeaseDuration != 42*time.Second {
+		t.Fatalf("lease duration = %v, want 42s", leader.LeaseDuration)
+	}
+}
+
+func TestNewFromEnvRejectsInvalidTTL(t *testing.T) {
+	t.Setenv("CONSENSUS_FILE_TTL", "soon")
+
+	if _, err := NewFromEnv(filepath.Join(t.TempDir(), "lease.json")); !errors.Is(err, consensus.ErrInvalidConfig) {
+		t.Fatalf("got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestBackendConformance(t *testing.T) {
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		return NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	})
+}
+
+func TestKVConformance(t *testing.T) {
+	consensustest.RunKVSuite(t, func(t *testing.T) consensus.KV {
+		return NewBackend(filepath.Join(t.TempDir(), "lease.json")).KV()
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		return NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	})
+}
+
+func TestHistoryRecordsEndReasons(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	ctx := context.Background()
+	b := NewBackend(path, WithHistoryLimit(3))
+
+	mustAcquire := func(identity string, d time.Duration) {
+		t.Helper()
+		if ok, err := b.TryAcquire(ctx, identity, d); err != nil || !ok {
+			t.Fatalf("acquire %s: ok=%v err=%v", identity, ok, err)
+		}
+	}
+
+	mustAcquire("a", time.Minute)
+	if err := b.Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	mustAcquire("b", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	mustAcquire("c", time.Minute)
+	if err := b.Transfer(ctx, "d"); err != nil {
+		t.Fatal(err)
+	}
+	// Claiming a transfer to the bare identity does not end d's tenure
+	if err := b.Transfer(ctx, "d#1f2e"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ForceRelease(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The history outlives the backend that wrote it, trimmed to the limit
+	history, err := NewBackend(path).History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, tenure := range history {
+		got = append(got, tenure.Identity+":"+string(tenure.Reason))
+	}
+	want := []string{"b:expired", "c:transferred", "d#1f2e:preempted"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("history = %v, want %v", got, want)
+	}
+	if history[0].Successor != "c" || !history[0].EndTime.Before(history[1].AcquireTime) {
+		t.Fatalf("expired tenure = %+v, want it to end at expiry before c took over", history[0])
+	}
+}
+
+func TestStateRequiresHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	ctx := context.Background()
+	b := NewBackend(path)
+
+	if state, err := b.GetState(ctx); err != nil || state != nil {
+		t.Fatalf("state before any lease = %q, err = %v; want none", state, err)
+	}
+	if ok, err := b.TryAcquire(ctx, "a", 50*time.Millisecond); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.SetState(ctx, "a", []byte("checkpoint")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetState(ctx, "b", []byte("intruder")); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("non-holder SetState: got %v, want ErrNotHolder", err)
+	}
+
+	// b takes over the lapsed lease from another process and sees a's state
+	time.Sleep(60 * time.Millisecond)
+	other := NewBackend(path)
+	if ok, err := other.TryAcquire(ctx, "b", time.Minute); err != nil || !ok {
+		t.Fatalf("takeover: ok=%v err=%v", ok, err)
+	}
+	if state, err := other.GetState(ctx); err != nil || string(state) != "checkpoint" {
+		t.Fatalf("state after takeover = %q, err = %v; want checkpoint", state, err)
+	}
+	if err := b.SetState(ctx, "a", []byte("stale")); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("deposed SetState: got %v, want ErrNotHolder", err)
+	}
+	if state, err := b.GetState(ctx); err != nil || string(state) != "checkpoint" {
+		t.Fatalf("state after rejected write = %q, err = %v; want checkpoint", state, err)
+	}
+}
+
+func TestClaimRequiresTransferredHolder(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+
+	if err := b.Claim(ctx, "pod-a", "pod-a#1f2e"); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("claim of free lease: got %v, want ErrNotHolder", err)
+	}
+	if ok, err := b.TryAcquire(ctx, "pod-b", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.Transfer(ctx, "pod-a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Claim(ctx, "pod-a", "pod-a#1f2e"); err != nil {
+		t.Fatal(err)
+	}
+	// A second session of the same identity arrives too late
+	if err := b.Claim(ctx, "pod-a", "pod-a#3c4d"); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("second claim: got %v, want ErrNotHolder", err)
+	}
+
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader.Identity != "pod-a#1f2e" {
+		t.Fatalf("holder = %q, want the first claimant", leader.Identity)
+	}
+}
@@ -0,0 +1,198 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// kvPollInterval is how often Watch rereads the key/value file.
+const kvPollInterval = 100 * time.Millisecond
+
+// kvData represents the JSON structure stored in the key/value file.
+type kvData struct {
+	Version  int                `json:"version"`
+	Revision uint64             `json:"revision"` // Incremented on every write; entry versions are taken from it
+	Entries  map[string]kvEntry `json:"entries,omitempty"`
+}
+
+// kvEntry is one value in the key/value file.
+type kvEntry struct {
+	Value    []byte `json:"value"`
+	Revision uint64 `json:"revision"`
+}
+
+// KV is a consensus.KV stored in a file next to the lease file. Writes take
+// the backend's lock, so the same lock mode applies.
+type KV struct {
+	b *Backend
+}
+
+// KV returns the key/value store sharing this backend's path and lock.
+// It is separate from the Backend because KV.Watch and consensus.Watcher
+// would otherwise collide.
+func (b *Backend) KV() *KV {
+	return &KV{b: b}
+}
+
+// Get returns the entry stored under key. The file is replaced atomically, so
+// reads don't take the lock.
+func (kv *KV) Get(ctx context.Context, key string) (consensus.KVEntry, error) {
+	data, err := kv.read()
+	if err != nil {
+		return consensus.KVEntry{}, err
+	}
+	return data.entry(key), nil
+}
+
+// CompareAndSwap stores value under key if its current version is oldVersion.
+func (kv *KV) CompareAndSwap(ctx context.Context, key, oldVersion string, value []byte) (string, error) {
+	var version string
+	_, err := kv.b.withLock(ctx, func() (bool, error) {
+		data, err := kv.read()
+		if err != nil {
+			return false, err
+		}
+		if current := data.entry(key).Version; current != oldVersion {
+			return false, fmt.Errorf("%w: %s is at version %q, not %q", consensus.ErrVersionConflict, key, current, oldVersion)
+		}
+
+		data.Revision++
+		if data.Entries == nil {
+			data.Entries = map[string]kvEntry{}
+		}
+		data.Entries[key] = kvEntry{Value: value, Revision: data.Revision}
+		if err := kv.write(data); err != nil {
+			return false, err
+		}
+
+		version = strconv.FormatUint(data.Revision, 10)
+		return true, nil
+	})
+
+	return version, err
+}
+
+// Delete removes key if its current version is version, or unconditionally if version is empty.
+func (kv *KV) Delete(ctx context.Context, key, version string) error {
+	_, err := kv.b.withLock(ctx, func() (bool, error) {
+		data, err := kv.read()
+		if err != nil {
+			return false, err
+		}
+
+		current := data.entry(key)
+		if !current.Exists() {
+			return true, nil
+		}
+		if version != "" && current.Version != version {
+			return false, fmt.Errorf("%w: %s is at version %q, not %q", consensus.ErrVersionConflict, key, current.Version, version)
+		}
+
+		data.Revision++
+		delete(data.Entries, key)
+		if err := kv.write(data); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+
+	return err
+}
+
+// Watch streams the entry under key whenever it changes, starting with the
+// current one. The file is polled, so changes are seen within kvPollInterval.
+// The channel is closed when ctx is cancelled or the file cannot be read.
+func (kv *KV) Watch(ctx context.Context, key string) (<-chan consensus.KVEntry, error) {
+	current, err := kv.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan consensus.KVEntry)
+	go func() {
+		defer close(ch)
+
+		ticker := time.NewTicker(kvPollInterval)
+		defer ticker.Stop()
+
+		entry := current
+		for {
+			select {
+			case ch <- entry:
+			case <-ctx.Done():
+				return
+			}
+			sent := entry
+
+			for entry.Version == sent.Version {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				if entry, err = kv.Get(ctx, key); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// entry returns key's value and version, or an empty entry if it is missing.
+func (d *kvData) entry(key string) consensus.KVEntry {
+	e, ok := d.Entries[key]
+	if !ok {
+		return consensus.KVEntry{Key: key}
+	}
+	return consensus.KVEntry{Key: key, Value: e.Value, Version: strconv.FormatUint(e.Revision, 10)}
+}
+
+// path returns the key/value file, kept apart from the lease file so
+// values don't bloat every lease read.
+func (kv *KV) path() string {
+	return kv.b.path + ".kv"
+}
+
+// read reads the key/value file. A missing or empty file holds no keys.
+func (kv *KV) read() (*kvData, error) {
+	raw, err := os.ReadFile(kv.path())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &kvData{}, nil
+		}
+		return nil, fmt.Errorf("failed to read key/value file: %w", err)
+	}
+	if len(raw) == 0 {
+		return &kvData{}, nil
+	}
+
+	var data kvData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode key/value file: %w", err)
+	}
+	if data.Version > formatVersion {
+		return nil, fmt.Errorf("unsupported key/value format version %d (max %d)", data.Version, formatVersion)
+	}
+
+	return &data, nil
+}
+
+// write writes the key/value file atomically.
+func (kv *KV) write(data *kvData) error {
+	data.Version = formatVersion
+
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode key/value file: %w", err)
+	}
+	return writeAtomic(kv.path(), append(raw, '\n'))
+}
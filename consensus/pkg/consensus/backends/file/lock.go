@@ -0,0 +1,276 @@
+package file
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// LockMode selects how the file backend serializes read-modify-write cycles.
+type LockMode int
+
+const (
+	// LockFlock uses flock(2) on <path>.lock. Fast and released automatically
+	// when the process dies, but unreliable or host-local on NFS/EFS.
+	LockFlock LockMode = iota
+
+	// LockExclusiveCreate creates <path>.lck with O_CREATE|O_EXCL and removes
+	// it on unlock. The lock file records its owner and creation time, and a
+	// lock older than the stale timeout is broken. Safe across hosts sharing a
+	// network filesystem, as long as their clocks are roughly in sync.
+	LockExclusiveCreate
+)
+
+// DefaultStaleLockTimeout is how old an exclusive-create lock file must be
+// before it is considered abandoned. Locks are only held for a single
+// read-modify-write, so anything this old belongs to a crashed process.
+const DefaultStaleLockTimeout = 10 * time.Second
+
+// lockRetryInterval is how often a contended exclusive-create lock is retried.
+const lockRetryInterval = 10 * time.Millisecond
+
+// lockOwner is the JSON content of an exclusive-create lock file.
+type lockOwner struct {
+	Host    string    `json:"host"`
+	PID     int       `json:"pid"`
+	Nonce   string    `json:"nonce"`
+	Created time.Time `json:"created"`
+}
+
+// withLock executes a function while holding the backend's lock.
+// The lock lives beside the lease file so the lease file itself can be
+// replaced atomically by rename.
+func (b *Backend) withLock(ctx context.Context, fn func() (bool, error)) (bool, error) {
+	switch b.lockMode {
+	case LockExclusiveCreate:
+		return b.withExclusiveLock(ctx, fn)
+	default:
+		return b.withFlock(fn)
+	}
+}
+
+// withFlock executes fn while holding an exclusive flock on <path>.lock.
+func (b *Backend) withFlock(fn func() (bool, error)) (bool, error) {
+	// Open or create the lock file
+	file, err := os.OpenFile(b.path+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	defer file.Close()
+
+	// Acquire exclusive lock
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return false, fmt.Errorf("failed to acquire file lock: %w", err)
+	}
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	return fn()
+}
+
+// withExclusiveLock executes fn while holding the exclusive-create lock file <path>.lck.
+func (b *Backend) withExclusiveLock(ctx context.Context, fn func() (bool, error)) (bool, error) {
+	lockPath := b.path + ".lck"
+
+	owner, err := newLockOwner()
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		created, err := createLockFile(lockPath, owner)
+		if err != nil {
+			return false, err
+		}
+		if created {
+			break
+		}
+
+		broken, err := breakStaleLock(lockPath, b.staleLock, owner)
+		if err != nil {
+			return false, err
+		}
+		if broken {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("failed to acquire file lock: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+	defer releaseLockFile(lockPath, owner.Nonce)
+
+	return fn()
+}
+
+// newLockOwner describes this process as a lock owner.
+func newLockOwner() (*lockOwner, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate lock nonce: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	return &lockOwner{
+		Host:    host,
+		PID:     os.Getpid(),
+		Nonce:   hex.EncodeToString(nonce),
+		Created: time.Now(),
+	}, nil
+}
+
+// createLockFile atomically creates the lock file and records the owner.
+// Returns false if the lock is held by someone else.
+func createLockFile(lockPath string, owner *lockOwner) (bool, error) {
+	file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create lock file: %w", err)
+	}
+	defer file.Close()
+
+	owner.Created = time.Now()
+	if err := json.NewEncoder(file).Encode(owner); err != nil {
+		os.Remove(lockPath)
+		return false, fmt.Errorf("failed to write lock owner: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		os.Remove(lockPath)
+		return false, fmt.Errorf("failed to sync lock file: %w", err)
+	}
+
+	return true, nil
+}
+
+// readLockOwner returns the owner recorded in the lock file.
+func readLockOwner(lockPath string) (*lockOwner, error) {
+	raw, err := os.ReadFile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner lockOwner
+	if err := json.Unmarshal(raw, &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// breakStaleLock takes over the lock file if it is older than staleAfter and
+// reports whether owner now holds it. The stale lock is replaced in one
+// rename by a file naming owner, so there is never a moment without a lock
+// file for a third process to create. Breakers are serialized by <lock>.break,
+// which names its creator like the lock does, and the lock is re-checked once
+// that is held, so two processes that both saw the same stale lock cannot
+// both replace it.
+func breakStaleLock(lockPath string, staleAfter time.Duration, owner *lockOwner) (bool, error) {
+	if stale, err := lockIsStale(lockPath, staleAfter); err != nil || !stale {
+		return false, err
+	}
+
+	breakPath := lockPath + ".break"
+	created, err := createLockFile(breakPath, owner)
+	if err != nil {
+		return false, err
+	}
+	if !created {
+		// Another process is breaking the lock; clear its marker only if it
+		// crashed doing so, and only the marker we judged stale
+		nonce := ownerNonce(breakPath)
+		if stale, err := lockIsStale(breakPath, staleAfter); err == nil && stale {
+			removeOwnedFile(breakPath, nonce, owner.Nonce)
+		}
+		return false, nil
+	}
+	defer removeOwnedFile(breakPath, owner.Nonce, owner.Nonce)
+
+	// The lock may have been released or broken while we waited
+	if stale, err := lockIsStale(lockPath, staleAfter); err != nil || !stale {
+		return false, err
+	}
+
+	tmpPath := lockPath + ".tmp-" + owner.Nonce
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to create replacement lock: %w", err)
+	}
+	owner.Created = time.Now()
+	err = json.NewEncoder(tmp).Encode(owner)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err == nil {
+		err = os.Rename(tmpPath, lockPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return false, fmt.Errorf("failed to break stale lock: %w", err)
+	}
+
+	// Confirm the lock we installed is the one in place
+	current, err := readLockOwner(lockPath)
+	return err == nil && current.Nonce == owner.Nonce, nil
+}
+
+// lockIsStale reports whether the lock file exists and is older than
+// staleAfter, judged by its recorded creation time or, if unreadable, its mtime.
+func lockIsStale(lockPath string, staleAfter time.Duration) (bool, error) {
+	stat, err := os.Stat(lockPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat lock file: %w", err)
+	}
+
+	created := stat.ModTime()
+	if owner, err := readLockOwner(lockPath); err == nil {
+		created = owner.Created
+	}
+	return time.Since(created) >= staleAfter, nil
+}
+
+// releaseLockFile removes the lock file if it is still ours.
+// A lock that was broken as stale and re-acquired by another process is left alone.
+func releaseLockFile(lockPath, nonce string) {
+	removeOwnedFile(lockPath, nonce, nonce)
+}
+
+// removeOwnedFile removes the lock or marker file at path if it still names
+// the owner with nonce, or is unreadable and nonce is empty. The file is
+// first renamed aside to a name unique to the caller's nonce by, so the check
+// and the removal apply to the same file even if a peer replaces path in
+// between; a file that turns out to be someone else's is renamed back.
+// Reports whether the file was removed.
+func removeOwnedFile(path, nonce, by string) bool {
+	aside := path + ".rm-" + by
+	if err := os.Rename(path, aside); err != nil {
+		return false
+	}
+	if ownerNonce(aside) != nonce {
+		os.Rename(aside, path)
+		return false
+	}
+	os.Remove(aside)
+	return true
+}
+
+// ownerNonce returns the nonce recorded in a lock or marker file, or "" if
+// the file is missing or unreadable, e.g. after a crash while creating it.
+func ownerNonce(path string) string {
+	owner, err := readLockOwner(path)
+	if err != nil {
+		return ""
+	}
+	return owner.Nonce
+}
@@ -0,0 +1,113 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// memberData represents the JSON structure stored in a membership file.
+type memberData struct {
+	Version    int           `json:"version"`
+	Holder     string        `json:"holder"`
+	Address    string        `json:"address,omitempty"`
+	Priority   int           `json:"priority,omitempty"`
+	AppVersion string        `json:"appVersion,omitempty"`
+	LastSeen   time.Time     `json:"lastSeen"`
+	TTL        time.Duration `json:"ttl"`
+}
+
+// Heartbeat writes member's record to its own file in the members directory
+// next to the lease file. Each member only writes its own file, so no lock is taken.
+func (b *Backend) Heartbeat(ctx context.Context, member consensus.Member) error {
+	raw, err := json.MarshalIndent(memberData{
+		Version:    formatVersion,
+		Holder:     member.Identity,
+		Address:    member.Address,
+		Priority:   member.Priority,
+		AppVersion: member.Version,
+		LastSeen:   member.LastSeen,
+		TTL:        member.TTL,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode member: %w", err)
+	}
+
+	if err := os.MkdirAll(b.membersDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create members directory: %w", err)
+	}
+	return writeAtomic(b.memberPath(member.Identity), append(raw, '\n'))
+}
+
+// Leave removes holder's membership file.
+func (b *Backend) Leave(ctx context.Context, holder string) error {
+	if err := os.Remove(b.memberPath(holder)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	return nil
+}
+
+// Members returns the members whose files have not expired.
+// Expired files left behind by crashed members are removed.
+func (b *Backend) Members(ctx context.Context) ([]consensus.Member, error) {
+	entries, err := os.ReadDir(b.membersDir())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	now := time.Now()
+	var members []consensus.Member
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(b.membersDir(), entry.Name())
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			// Removed by Leave since the listing
+			continue
+		}
+		var data memberData
+		if err := json.Unmarshal(raw, &data); err != nil || data.Version > formatVersion {
+			continue
+		}
+
+		member := consensus.Member{
+			Identity: data.Holder,
+			Address:  data.Address,
+			Priority: data.Priority,
+			Version:  data.AppVersion,
+			LastSeen: data.LastSeen,
+			TTL:      data.TTL,
+		}
+		if member.Expired(now) {
+			_ = os.Remove(path)
+			continue
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// membersDir returns the directory holding one file per member.
+func (b *Backend) membersDir() string {
+	return b.path + ".members"
+}
+
+// memberPath returns the file for holder, escaped so any identity is a valid file name.
+func (b *Backend) memberPath(holder string) string {
+	return filepath.Join(b.membersDir(), url.PathEscape(holder)+".json")
+}
@@ -0,0 +1,144 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Event reasons recorded by EventRecorder.
+const (
+	ReasonBecameLeader   = "BecameLeader"
+	ReasonLostLeadership = "LostLeadership"
+	ReasonRenewFailed    = "RenewFailed"
+	ReasonReleased       = "Released"
+)
+
+// EventRecorderOption configures an EventRecorder.
+type EventRecorderOption func(*EventRecorder)
+
+// WithPodEvents also records every event on the named pod.
+func WithPodEvents(podName string) EventRecorderOption {
+	return func(r *EventRecorder) {
+		r.podName = podName
+	}
+}
+
+// WithComponent sets the event source component (default: "consensus").
+func WithComponent(component string) EventRecorderOption {
+	return func(r *EventRecorder) {
+		r.component = component
+	}
+}
+
+// EventRecorder is a consensus.Hook that records core/v1 Events on the
+// Lease object, so `kubectl describe lease` shows why leadership moved.
+// Events go through client-go's broadcaster, which aggregates and
+// rate-limits repeated events.
+//
+// It needs RBAC to create and patch events in the namespace.
+type EventRecorder struct {
+	client      kubernetes.Interface
+	namespace   string
+	leaseName   string
+	podName     string
+	component   string
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+}
+
+var _ consensus.Hook = (*EventRecorder)(nil)
+
+// NewEventRecorder creates a hook that records events on the Lease leaseName.
+// Call Close to flush and stop the recorder on shutdown.
+func NewEventRecorder(client kubernetes.Interface, namespace, leaseName string, opts ...EventRecorderOption) *EventRecorder {
+	r := &EventRecorder{
+		client:    client,
+		namespace: namespace,
+		leaseName: leaseName,
+		component: "consensus",
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.broadcaster = record.NewBroadcaster()
+	r.broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(namespace)})
+	r.recorder = r.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: r.component})
+	return r
+}
+
+// OnLeadershipEvent records event on the Lease and, if configured, the pod.
+func (r *EventRecorder) OnLeadershipEvent(ctx context.Context, event consensus.Event) {
+	eventType, reason, message := describe(event)
+
+	r.recorder.Event(r.leaseRef(ctx), eventType, reason, message)
+	if r.podName != "" {
+		r.recorder.Event(r.podRef(ctx), eventType, reason, message)
+	}
+}
+
+// Close stops the recorder. Events already queued are still delivered.
+func (r *EventRecorder) Close() {
+	r.broadcaster.Shutdown()
+}
+
+// leaseRef references the Lease, including its UID when it can be read so
+// that `kubectl describe` associates the event with the object.
+func (r *EventRecorder) leaseRef(ctx context.Context) *corev1.ObjectReference {
+	ref := &corev1.ObjectReference{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Namespace:  r.namespace,
+		Name:       r.leaseName,
+	}
+	if lease, err := r.client.CoordinationV1().Leases(r.namespace).Get(ctx, r.leaseName, metav1.GetOptions{}); err == nil {
+		ref.UID = lease.UID
+		ref.ResourceVersion = lease.ResourceVersion
+	}
+	return ref
+}
+
+// podRef references this pod, including its UID when it can be read.
+func (r *EventRecorder) podRef(ctx context.Context) *corev1.ObjectReference {
+	ref := &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Namespace:  r.namespace,
+		Name:       r.podName,
+	}
+	if pod, err := r.client.CoreV1().Pods(r.namespace).Get(ctx, r.podName, metav1.GetOptions{}); err == nil {
+		ref.UID = pod.UID
+		ref.ResourceVersion = pod.ResourceVersion
+	}
+	return ref
+}
+
+// describe maps a leadership event to an Event type, reason and message.
+func describe(event consensus.Event) (string, string, string) {
+	var eventType, reason, message string
+	switch event.Type {
+	case consensus.EventAcquired:
+		eventType, reason, message = corev1.EventTypeNormal, ReasonBecameLeader, event.Identity+" became leader"
+	case consensus.EventLost:
+		eventType, reason, message = corev1.EventTypeWarning, ReasonLostLeadership, event.Identity+" lost leadership"
+	case consensus.EventRenewFailed:
+		eventType, reason, message = corev1.EventTypeWarning, ReasonRenewFailed, event.Identity+" failed to renew the lease"
+	case consensus.EventReleased:
+		eventType, reason, message = corev1.EventTypeNormal, ReasonReleased, event.Identity+" released leadership"
+	default:
+		eventType, reason, message = corev1.EventTypeNormal, string(event.Type), fmt.Sprintf("%s: %s", event.Identity, event.Type)
+	}
+
+	if event.Err != nil {
+		message += ": " + event.Err.Error()
+	}
+	return eventType, reason, message
+}
@@ -0,0 +1,59 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+func TestEventRecorderRecordsOnLease(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset(
+		&coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default", UID: "lease-uid"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: "default", UID: "pod-uid"}},
+	)
+	r := NewEventRecorder(client, "default", "demo", WithPodEvents("pod-a"))
+	defer r.Close()
+
+	r.OnLeadershipEvent(ctx, consensus.Event{Type: consensus.EventAcquired, Identity: "pod-a"})
+	r.OnLeadershipEvent(ctx, consensus.Event{Type: consensus.EventRenewFailed, Identity: "pod-a", Err: errors.New("timeout")})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		events, err := client.CoreV1().Events("default").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		seen := map[string]corev1.Event{}
+		for _, e := range events.Items {
+			seen[e.InvolvedObject.Kind+"/"+e.Reason] = e
+		}
+		if len(seen) == 4 {
+			renew := seen["Lease/"+ReasonRenewFailed]
+			if renew.Type != corev1.EventTypeWarning || renew.Message != "pod-a failed to renew the lease: timeout" {
+				t.Fatalf("renew event = %s %q", renew.Type, renew.Message)
+			}
+			if uid := seen["Lease/"+ReasonBecameLeader].InvolvedObject.UID; uid != "lease-uid" {
+				t.Fatalf("lease event UID = %q", uid)
+			}
+			if uid := seen["Pod/"+ReasonBecameLeader].InvolvedObject.UID; uid != "pod-uid" {
+				t.Fatalf("pod event UID = %q", uid)
+			}
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("recorded events = %v", seen)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,115 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// historyKey is the history ConfigMap data entry holding the JSON tenures.
+const historyKey = "history"
+
+// History returns the past tenures recorded in the companion ConfigMap, oldest first.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	cm, err := b.client.CoreV1().ConfigMaps(b.namespace).Get(ctx, b.historyName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	var history consensus.History
+	if raw := cm.Data[historyKey]; raw != "" {
+		if err := json.Unmarshal([]byte(raw), &history); err != nil {
+			return nil, fmt.Errorf("failed to decode history: %w", err)
+		}
+	}
+	return history, nil
+}
+
+// recordTenure appends tenure to the companion history ConfigMap, creating it
+// if needed. It runs after the Lease update that ended the tenure, and a
+// failure is not reported: the transition has already happened.
+func (b *Backend) recordTenure(ctx context.Context, tenure consensus.Tenure) {
+	client := b.client.CoreV1().ConfigMaps(b.namespace)
+
+	_ = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := client.Get(ctx, b.historyName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			raw, err := json.Marshal(consensus.History{tenure})
+			if err != nil {
+				return err
+			}
+			_, err = client.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: b.objectMeta(b.historyName(), nil, nil),
+				Data:       map[string]string{historyKey: string(raw)},
+			}, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Created concurrently; retry as an update
+				return apierrors.NewConflict(corev1.Resource("configmaps"), b.historyName(), err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		// Undecodable history is replaced rather than blocking new records
+		var history consensus.History
+		_ = json.Unmarshal([]byte(cm.Data[historyKey]), &history)
+
+		raw, err := json.Marshal(history.Append(tenure, b.historyLimit))
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[historyKey] = string(raw)
+
+		_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// historyName returns the companion ConfigMap holding the Lease's history.
+func (b *Backend) historyName() string {
+	return b.name + "-history"
+}
+
+// endedTenure describes the tenure of lease's current holder, ending at now
+// for reason. A lease that had already lapsed is recorded as expired at its
+// expiry, whatever ended it. It reports false if the lease has no holder.
+func endedTenure(lease *coordinationv1.Lease, reason consensus.EndReason, now time.Time, successor string) (consensus.Tenure, bool) {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return consensus.Tenure{}, false
+	}
+
+	tenure := consensus.Tenure{
+		Identity:  *lease.Spec.HolderIdentity,
+		Term:      uint64(transitions(lease)),
+		EndTime:   now,
+		Reason:    reason,
+		Successor: successor,
+	}
+	if lease.Spec.AcquireTime != nil {
+		tenure.AcquireTime = lease.Spec.AcquireTime.Time
+	}
+	if lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
+		expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+		if !now.Before(expiry) {
+			tenure.Reason = consensus.EndExpired
+			tenure.EndTime = expiry
+		}
+	}
+	return tenure, true
+}
@@ -0,0 +1,227 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// KVOfLabel is set on key/value ConfigMaps to the name of the election Lease.
+	KVOfLabel = "consensus.fraser.dev/kv-of"
+	// KeyAnnotation is the key/value ConfigMap annotation holding the unmangled key.
+	KeyAnnotation = "consensus.fraser.dev/key"
+
+	// kvValueKey is the ConfigMap binaryData entry holding the value.
+	kvValueKey = "value"
+	// kvRewatchInterval is how long Watch waits before reopening a failed watch.
+	kvRewatchInterval = time.Second
+)
+
+// KV is a consensus.KV keeping each key in its own ConfigMap, labelled with
+// KVOfLabel. Versions are ConfigMap resourceVersions, so writes are
+// compare-and-swap through the API server's optimistic concurrency.
+type KV struct {
+	b *Backend
+}
+
+// KV returns the key/value store kept alongside this backend's Lease.
+// It is separate from the Backend because KV.Watch and consensus.Watcher
+// would otherwise collide.
+func (b *Backend) KV() *KV {
+	return &KV{b: b}
+}
+
+// Get returns the entry stored under key.
+func (kv *KV) Get(ctx context.Context, key string) (consensus.KVEntry, error) {
+	cm, err := kv.b.client.CoreV1().ConfigMaps(kv.b.namespace).Get(ctx, kv.name(key), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return consensus.KVEntry{Key: key}, nil
+		}
+		return consensus.KVEntry{}, fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	return kvEntry(key, cm), nil
+}
+
+// CompareAndSwap stores value under key if its current version is oldVersion.
+func (kv *KV) CompareAndSwap(ctx context.Context, key, oldVersion string, value []byte) (string, error) {
+	client := kv.b.client.CoreV1().ConfigMaps(kv.b.namespace)
+
+	if oldVersion == "" {
+		cm, err := client.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: kv.b.objectMeta(kv.name(key),
+				map[string]string{KVOfLabel: kv.b.name},
+				map[string]string{KeyAnnotation: key}),
+			BinaryData: map[string][]byte{kvValueKey: value},
+		}, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("%w: %s already exists", consensus.ErrVersionConflict, key)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create key %s: %w", key, err)
+		}
+		return cm.ResourceVersion, nil
+	}
+
+	cm, err := client.Get(ctx, kv.name(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("%w: %s does not exist", consensus.ErrVersionConflict, key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	if cm.ResourceVersion != oldVersion {
+		return "", fmt.Errorf("%w: %s is at version %q, not %q", consensus.ErrVersionConflict, key, cm.ResourceVersion, oldVersion)
+	}
+
+	// The API server rejects the update if the ConfigMap changed since the Get
+	cm.BinaryData = map[string][]byte{kvValueKey: value}
+	cm, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return "", fmt.Errorf("%w: %s changed concurrently", consensus.ErrVersionConflict, key)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to update key %s: %w", key, err)
+	}
+	return cm.ResourceVersion, nil
+}
+
+// Delete removes key if its current version is version, or unconditionally if version is empty.
+func (kv *KV) Delete(ctx context.Context, key, version string) error {
+	client := kv.b.client.CoreV1().ConfigMaps(kv.b.namespace)
+
+	var opts metav1.DeleteOptions
+	if version != "" {
+		cm, err := client.Get(ctx, kv.name(key), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get key %s: %w", key, err)
+		}
+		if cm.ResourceVersion != version {
+			return fmt.Errorf("%w: %s is at version %q, not %q", consensus.ErrVersionConflict, key, cm.ResourceVersion, version)
+		}
+		opts.Preconditions = &metav1.Preconditions{ResourceVersion: &version}
+	}
+
+	err := client.Delete(ctx, kv.name(key), opts)
+	if apierrors.IsConflict(err) {
+		return fmt.Errorf("%w: %s changed concurrently", consensus.ErrVersionConflict, key)
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete key %s: %w", key, err)
+	}
+	return nil
+}
+
+// Watch streams the entry under key whenever it changes, starting with the
+// current one. The underlying watch is reopened when the API server ends it,
+// so the channel is only closed when ctx is cancelled.
+func (kv *KV) Watch(ctx context.Context, key string) (<-chan consensus.KVEntry, error) {
+	current, err := kv.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan consensus.KVEntry)
+	go func() {
+		defer close(ch)
+
+		sent := consensus.KVEntry{Version: "-"}
+		entry := current
+		for {
+			if entry.Version != sent.Version {
+				select {
+				case ch <- entry:
+					sent = entry
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			entry = kv.watchOnce(ctx, key, sent)
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			if entry.Version == sent.Version {
+				// The watch ended without a change; back off before reopening
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(kvRewatchInterval):
+				}
+				if e, err := kv.Get(ctx, key); err == nil {
+					entry = e
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// watchOnce watches key's ConfigMap from last's version and returns the first
+// change, or last if the watch ends or fails first.
+func (kv *KV) watchOnce(ctx context.Context, key string, last consensus.KVEntry) consensus.KVEntry {
+	w, err := kv.b.client.CoreV1().ConfigMaps(kv.b.namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector:   "metadata.name=" + kv.name(key),
+		ResourceVersion: last.Version,
+	})
+	if err != nil {
+		return last
+	}
+	defer w.Stop()
+
+	// Catch changes made before the watch was established
+	if current, err := kv.Get(ctx, key); err == nil && current.Version != last.Version {
+		return current
+	}
+
+	for {
+		var event watch.Event
+		select {
+		case <-ctx.Done():
+			return last
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				return last
+			}
+			event = e
+		}
+
+		cm, ok := event.Object.(*corev1.ConfigMap)
+		if !ok || cm.Name != kv.name(key) {
+			continue
+		}
+		switch event.Type {
+		case watch.Added, watch.Modified:
+			if cm.ResourceVersion != last.Version {
+				return kvEntry(key, cm)
+			}
+		case watch.Deleted:
+			if last.Exists() {
+				return consensus.KVEntry{Key: key}
+			}
+		}
+	}
+}
+
+// name returns the ConfigMap holding key.
+func (kv *KV) name(key string) string {
+	return objectName(kv.b.name+"-kv-"+key, key)
+}
+
+// kvEntry converts a key's ConfigMap to an entry.
+func kvEntry(key string, cm *corev1.ConfigMap) consensus.KVEntry {
+	return consensus.KVEntry{Key: key, Value: cm.BinaryData[kvValueKey], Version: cm.ResourceVersion}
+}
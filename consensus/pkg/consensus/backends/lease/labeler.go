@@ -0,0 +1,164 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultLeaderLabelKey is the pod label set on the leader by default.
+	DefaultLeaderLabelKey = "role"
+	// DefaultLeaderLabelValue is the value of DefaultLeaderLabelKey on the leader.
+	DefaultLeaderLabelValue = "leader"
+)
+
+// LabelerOption configures a PodLabeler.
+type LabelerOption func(*PodLabeler)
+
+// WithLeaderLabel sets the label placed on the leader pod (default: role=leader).
+func WithLeaderLabel(key, value string) LabelerOption {
+	return func(l *PodLabeler) {
+		l.key = key
+		l.value = value
+		l.annotation = false
+	}
+}
+
+// WithLeaderAnnotation marks the leader pod with an annotation instead of a label.
+// Annotations cannot be used in Service selectors.
+func WithLeaderAnnotation(key, value string) LabelerOption {
+	return func(l *PodLabeler) {
+		l.key = key
+		l.value = value
+		l.annotation = true
+	}
+}
+
+// PodLabeler is a consensus.Hook that marks the leader's pod, so leader-only
+// Services can select it. On acquisition it also strips the marker from any
+// other pod in the namespace, cleaning up after leaders that crashed.
+//
+// It needs RBAC to get, list and patch pods in the namespace.
+type PodLabeler struct {
+	client     kubernetes.Interface
+	namespace  string
+	podName    string
+	key        string
+	value      string
+	annotation bool
+}
+
+var _ consensus.Hook = (*PodLabeler)(nil)
+
+// NewPodLabeler creates a hook that labels podName while it leads.
+func NewPodLabeler(client kubernetes.Interface, namespace, podName string, opts ...LabelerOption) *PodLabeler {
+	l := &PodLabeler{
+		client:    client,
+		namespace: namespace,
+		podName:   podName,
+		key:       DefaultLeaderLabelKey,
+		value:     DefaultLeaderLabelValue,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// NewPodLabelerFromEnv creates a PodLabeler for the pod named by POD_NAME.
+// Environment variables:
+//
+//	POD_NAME      - name of this pod (required)
+//	POD_NAMESPACE - namespace of this pod (default: "default")
+func NewPodLabelerFromEnv(client kubernetes.Interface, opts ...LabelerOption) (*PodLabeler, error) {
+	podName := os.Getenv("POD_NAME")
+	if podName == "" {
+		return nil, fmt.Errorf("%w: POD_NAME is not set", ErrInvalidConfig)
+	}
+
+	namespace := os.Getenv("POD_NAMESPACE")
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	return NewPodLabeler(client, namespace, podName, opts...), nil
+}
+
+// OnLeadershipEvent labels the pod on acquisition and unlabels it otherwise.
+// Failures are not fatal: the next acquisition reconciles the labels again.
+func (l *PodLabeler) OnLeadershipEvent(ctx context.Context, event consensus.Event) {
+	switch event.Type {
+	case consensus.EventAcquired:
+		_ = l.Reconcile(ctx)
+	case consensus.EventLost, consensus.EventReleased:
+		_ = l.patch(ctx, l.podName, nil)
+	}
+}
+
+// Reconcile removes the marker from every other pod and sets it on this one.
+func (l *PodLabeler) Reconcile(ctx context.Context) error {
+	if err := l.removeStale(ctx); err != nil {
+		return err
+	}
+	return l.patch(ctx, l.podName, &l.value)
+}
+
+// removeStale strips the marker from pods other than this one.
+func (l *PodLabeler) removeStale(ctx context.Context) error {
+	listOpts := metav1.ListOptions{}
+	if !l.annotation {
+		listOpts.LabelSelector = l.key + "=" + l.value
+	}
+
+	pods, err := l.client.CoreV1().Pods(l.namespace).List(ctx, listOpts)
+	if err != nil {
+		return fmt.Errorf("list pods: %w", err)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Name == l.podName {
+			continue
+		}
+		marks := pod.Labels
+		if l.annotation {
+			marks = pod.Annotations
+		}
+		if _, ok := marks[l.key]; !ok {
+			continue
+		}
+		if err := l.patch(ctx, pod.Name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patch sets the marker on a pod, or removes it when value is nil.
+func (l *PodLabeler) patch(ctx context.Context, podName string, value *string) error {
+	field := "labels"
+	if l.annotation {
+		field = "annotations"
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			field: map[string]*string{l.key: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = l.client.CoreV1().Pods(l.namespace).Patch(ctx, podName, types.MergePatchType, body, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("patch pod %s: %w", podName, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package lease
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+func pod(name string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels}}
+}
+
+func labelsOf(t *testing.T, l *PodLabeler, name string) map[string]string {
+	t.Helper()
+	p, err := l.client.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.Labels
+}
+
+func TestPodLabelerFollowsLeadership(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset(
+		pod("a", map[string]string{"app": "demo"}),
+		pod("b", map[string]string{"app": "demo", "role": "leader"}), // crashed former leader
+	)
+	l := NewPodLabeler(client, "default", "a")
+
+	l.OnLeadershipEvent(ctx, consensus.Event{Type: consensus.EventAcquired})
+	if got := labelsOf(t, l, "a")["role"]; got != "leader" {
+		t.Fatalf("leader label = %q, want leader", got)
+	}
+	if _, ok := labelsOf(t, l, "b")["role"]; ok {
+		t.Fatal("stale leader label not removed from b")
+	}
+	if got := labelsOf(t, l, "b")["app"]; got != "demo" {
+		t.Fatalf("unrelated label changed: app=%q", got)
+	}
+
+	l.OnLeadershipEvent(ctx, consensus.Event{Type: consensus.EventReleased})
+	if _, ok := labelsOf(t, l, "a")["role"]; ok {
+		t.Fatal("leader label not removed on release")
+	}
+}
+
+func TestPodLabelerAnnotation(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset(pod("a", nil))
+	l := NewPodLabeler(client, "default", "a", WithLeaderAnnotation("example.com/leader", "true"))
+
+	l.OnLeadershipEvent(ctx, consensus.Event{Type: consensus.EventAcquired})
+	p, err := client.CoreV1().Pods("default").Get(ctx, "a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Annotations["example.com/leader"] != "true" {
+		t.Fatalf("annotations = %v", p.Annotations)
+	}
+
+	l.OnLeadershipEvent(ctx, consensus.Event{Type: consensus.EventLost})
+	p, _ = client.CoreV1().Pods("default").Get(ctx, "a", metav1.GetOptions{})
+	if _, ok := p.Annotations["example.com/leader"]; ok {
+		t.Fatal("annotation not removed on loss")
+	}
+}
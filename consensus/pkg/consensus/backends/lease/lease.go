@@ -2,16 +2,25 @@ package lease
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
 	"os"
+	"slices"
+	"strconv"
 	"time"
 
+	"github.com/fraser/consensus/pkg/consensus"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
 )
 
 var (
@@ -19,30 +28,104 @@ var (
 	ErrK8sConnection = errors.New("failed to connect to kubernetes")
 	// ErrInvalidConfig indicates invalid configuration
 	ErrInvalidConfig = errors.New("invalid configuration")
+	// ErrStateTooLarge indicates a state payload does not fit in a Lease annotation
+	ErrStateTooLarge = errors.New("state payload too large")
+)
+
+const (
+	// StateAnnotation is the Lease annotation holding the base64-encoded leader state.
+	StateAnnotation = "consensus.fraser.dev/state"
+	// AddressAnnotation is the Lease annotation holding the leader's advertised address.
+	AddressAnnotation = "consensus.fraser.dev/address"
+	// QueueAnnotation is the Lease annotation holding the JSON candidate queue.
+	QueueAnnotation = "consensus.fraser.dev/queue"
+
+	// maxStateSize bounds the encoded state so the Lease stays well under the
+	// 256KiB total annotation limit enforced by the API server.
+	maxStateSize = 128 * 1024
 )
 
 // Backend implements consensus.Backend using Kubernetes Lease objects.
 type Backend struct {
-	client    kubernetes.Interface
-	namespace string
-	name      string
+	client       kubernetes.Interface
+	namespace    string
+	name         string
+	historyLimit int
+	ttl          time.Duration
+	labels       map[string]string
+	annotations  map[string]string
+	owners       []metav1.OwnerReference
+}
+
+// Option configures a Backend.
+type Option func(*Backend)
+
+// WithHistoryLimit sets how many past tenures the history ConfigMap keeps
+// (default: consensus.DefaultHistoryLimit).
+func WithHistoryLimit(n int) Option {
+	return func(b *Backend) {
+		b.historyLimit = n
+	}
+}
+
+// WithTTL fixes the lease duration written to the Lease, overriding the
+// duration requested by the Manager. Zero keeps the requested duration.
+func WithTTL(ttl time.Duration) Option {
+	return func(b *Backend) {
+		b.ttl = ttl
+	}
+}
+
+// WithLabels adds labels to the objects the backend creates.
+// Labels the backend relies on, such as MemberOfLabel, take precedence.
+func WithLabels(labels map[string]string) Option {
+	return func(b *Backend) {
+		b.labels = maps.Clone(labels)
+	}
+}
+
+// WithAnnotations adds annotations to the objects the backend creates.
+// Annotations the backend relies on take precedence.
+func WithAnnotations(annotations map[string]string) Option {
+	return func(b *Backend) {
+		b.annotations = maps.Clone(annotations)
+	}
+}
+
+// WithOwnerReferences sets the owners of the objects the backend creates,
+// so Kubernetes garbage-collects them along with, e.g., the Deployment
+// running the election.
+func WithOwnerReferences(owners ...metav1.OwnerReference) Option {
+	return func(b *Backend) {
+		b.owners = slices.Clone(owners)
+	}
 }
 
 // NewBackend creates a new Kubernetes Lease backend.
-func NewBackend(client kubernetes.Interface, namespace, name string) *Backend {
-	return &Backend{
+func NewBackend(client kubernetes.Interface, namespace, name string, opts ...Option) *Backend {
+	b := &Backend{
 		client:    client,
 		namespace: namespace,
 		name:      name,
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
-// NewFromEnv creates a Lease backend using in-cluster Kubernetes config.
+// NewFromEnv creates a Lease backend, using the in-cluster Kubernetes config
+// when running in a pod and a kubeconfig file otherwise.
 // leaseName: name of the Lease object to use for coordination
 // Environment variables:
 //
-//	POD_NAMESPACE - namespace for lease object (default: "default")
-func NewFromEnv(leaseName string) (*Backend, error) {
+//	POD_NAMESPACE       - namespace for lease object (default: "default")
+//	CONSENSUS_LEASE_TTL - lease duration in whole seconds (default: "15")
+//	KUBECONFIG          - kubeconfig used outside a cluster (default: "$HOME/.kube/config")
+//
+// Errors wrap ErrInvalidConfig for an empty name or an unparseable or
+// non-positive TTL, and ErrK8sConnection when no client can be built.
+func NewFromEnv(leaseName string, opts ...Option) (*Backend, error) {
 	if leaseName == "" {
 		return nil, fmt.Errorf("%w: leaseName cannot be empty", ErrInvalidConfig)
 	}
@@ -52,7 +135,16 @@ func NewFromEnv(leaseName string) (*Backend, error) {
 		namespace = "default"
 	}
 
-	config, err := rest.InClusterConfig()
+	ttl := 15 * time.Second
+	if raw := os.Getenv("CONSENSUS_LEASE_TTL"); raw != "" {
+		secs, err := strconv.Atoi(raw)
+		if err != nil || secs <= 0 {
+			return nil, fmt.Errorf("%w: CONSENSUS_LEASE_TTL must be a positive number of seconds, got %q", ErrInvalidConfig, raw)
+		}
+		ttl = time.Duration(secs) * time.Second
+	}
+
+	config, err := restConfig()
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrK8sConnection, err)
 	}
@@ -62,11 +154,34 @@ func NewFromEnv(leaseName string) (*Backend, error) {
 		return nil, fmt.Errorf("%w: failed to create clientset: %v", ErrK8sConnection, err)
 	}
 
-	return NewBackend(clientset, namespace, leaseName), nil
+	return NewBackend(clientset, namespace, leaseName, append([]Option{WithTTL(ttl)}, opts...)...), nil
+}
+
+// restConfig returns the in-cluster config, falling back to the kubeconfig
+// file for local development.
+func restConfig() (*rest.Config, error) {
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		return config, nil
+	}
+
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		kubeconfig = os.ExpandEnv("$HOME/.kube/config")
+	}
+	config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("no in-cluster config and kubeconfig %s unusable: %v", kubeconfig, err)
+	}
+	return config, nil
 }
 
 // TryAcquire attempts to acquire or renew leadership.
 func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	seconds, err := leaseSeconds(b.leaseDuration(leaseDuration))
+	if err != nil {
+		return false, err
+	}
 	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
 
 	lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
@@ -77,13 +192,10 @@ func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration
 
 		// Lease doesn't exist - create it
 		lease = &coordinationv1.Lease{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      b.name,
-				Namespace: b.namespace,
-			},
+			ObjectMeta: b.objectMeta(b.name, nil, nil),
 			Spec: coordinationv1.LeaseSpec{
 				HolderIdentity:       &identity,
-				LeaseDurationSeconds: ptr(int32(leaseDuration.Seconds())),
+				LeaseDurationSeconds: ptr(seconds),
 				AcquireTime:          &metav1.MicroTime{Time: time.Now()},
 				RenewTime:            &metav1.MicroTime{Time: time.Now()},
 			},
@@ -106,17 +218,20 @@ func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration
 
 	// If we're already the holder, renew it
 	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == identity {
-		lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
-		lease.Spec.LeaseDurationSeconds = ptr(int32(leaseDuration.Seconds()))
-		_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+		err := b.renew(ctx, identity, seconds)
+		if errors.Is(err, consensus.ErrNotHolder) {
+			// Lost between reads; campaign again on the next attempt
+			return false, nil
+		}
 		if err != nil {
-			return false, fmt.Errorf("failed to renew lease: %w", err)
+			return false, err
 		}
 		return true, nil
 	}
 
-	// Different holder - check if lease has expired
-	if lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
+	// Different holder - check if lease has expired. A released lease has no holder and is free.
+	held := lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != ""
+	if held && lease.Spec.RenewTime != nil && lease.Spec.LeaseDurationSeconds != nil {
 		elapsed := now.Sub(lease.Spec.RenewTime.Time)
 		ttl := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
 		if elapsed < ttl {
@@ -126,10 +241,13 @@ func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration
 	}
 
 	// Lease has expired - take it over
+	tenure, ended := endedTenure(lease, consensus.EndExpired, now, identity)
+	delete(lease.Annotations, AddressAnnotation)
+	lease.Spec.LeaseTransitions = ptr(transitions(lease) + 1)
 	lease.Spec.HolderIdentity = &identity
 	lease.Spec.AcquireTime = &metav1.MicroTime{Time: now}
 	lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
-	lease.Spec.LeaseDurationSeconds = ptr(int32(leaseDuration.Seconds()))
+	lease.Spec.LeaseDurationSeconds = ptr(seconds)
 
 	_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
 	if err != nil {
@@ -139,59 +257,431 @@ func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration
 		}
 		return false, fmt.Errorf("failed to acquire expired lease: %w", err)
 	}
+	if ended {
+		b.recordTenure(ctx, tenure)
+	}
 
 	return true, nil
 }
 
 // Renew extends the current leader's lease.
 func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
-	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
-
-	lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
+	seconds, err := leaseSeconds(b.leaseDuration(leaseDuration))
 	if err != nil {
-		return fmt.Errorf("failed to get lease for renewal: %w", err)
+		return err
 	}
+	return b.renew(ctx, identity, seconds)
+}
 
-	// Verify we're the holder
-	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity {
-		return fmt.Errorf("not the lease holder")
-	}
+// renew sets the renewal time and duration if identity holds the Lease.
+// Conflicts (e.g. with followers writing the queue annotation) are retried,
+// repeating the holder check against each fresh read.
+func (b *Backend) renew(ctx context.Context, identity string, seconds int32) error {
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+
+	var updateErr error
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return consensus.ErrNotHolder
+			}
+			return fmt.Errorf("failed to get lease for renewal: %w", err)
+		}
 
-	// Update renewal time and duration
-	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
-	lease.Spec.LeaseDurationSeconds = ptr(int32(leaseDuration.Seconds()))
+		// Verify we're the holder
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity {
+			return consensus.ErrNotHolder
+		}
 
-	_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
-	if err != nil {
+		// Update renewal time and duration
+		lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+		lease.Spec.LeaseDurationSeconds = ptr(seconds)
+
+		_, updateErr = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+		return updateErr
+	})
+	if err != nil && err == updateErr {
 		return fmt.Errorf("failed to update lease: %w", err)
 	}
 
-	return nil
+	return err
 }
 
 // Release explicitly gives up leadership.
+// Conflicts are retried like Renew's, so a follower's queue write cannot
+// leave the lease held until it expires.
 func (b *Backend) Release(ctx context.Context, identity string) error {
 	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
 
+	var (
+		tenure    consensus.Tenure
+		ended     bool
+		updateErr error
+	)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// Lease doesn't exist - nothing to release
+				ended = false
+				return nil
+			}
+			return fmt.Errorf("failed to get lease for release: %w", err)
+		}
+
+		// Only release if we're the holder
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity {
+			ended = false
+			return nil
+		}
+
+		tenure, ended = endedTenure(lease, consensus.EndReleased, time.Now(), "")
+		lease.Spec.HolderIdentity = nil
+		delete(lease.Annotations, AddressAnnotation)
+		_, updateErr = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+		return updateErr
+	})
+	if err != nil {
+		if err == updateErr {
+			return fmt.Errorf("failed to release lease: %w", err)
+		}
+		return err
+	}
+	if ended {
+		b.recordTenure(ctx, tenure)
+	}
+
+	return nil
+}
+
+// GetLeader returns the current lease holder, or nil if the lease is free or expired.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+
 	lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			// Lease doesn't exist - nothing to release
-			return nil
+			return nil, nil
 		}
-		return fmt.Errorf("failed to get lease for release: %w", err)
+		return nil, fmt.Errorf("failed to get lease: %w", err)
 	}
 
-	// Only release if we're the holder
-	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == identity {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return nil, nil
+	}
+
+	record := &consensus.LeaderRecord{
+		Identity: *lease.Spec.HolderIdentity,
+		Address:  lease.Annotations[AddressAnnotation],
+	}
+	if lease.Spec.AcquireTime != nil {
+		record.AcquireTime = lease.Spec.AcquireTime.Time
+	}
+	if lease.Spec.RenewTime != nil {
+		record.RenewTime = lease.Spec.RenewTime.Time
+	}
+	if lease.Spec.LeaseDurationSeconds != nil {
+		record.LeaseDuration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	record.Transitions = uint64(transitions(lease))
+
+	if time.Since(record.RenewTime) >= record.LeaseDuration {
+		return nil, nil
+	}
+
+	return record, nil
+}
+
+// SetAddress records the holder's advertised address in a Lease annotation.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	return b.updateAnnotation(ctx, identity, AddressAnnotation, address)
+}
+
+// ForceRelease clears the Lease holder regardless of who holds it.
+func (b *Backend) ForceRelease(ctx context.Context) error {
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+
+	var tenure consensus.Tenure
+	var ended bool
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get lease: %w", err)
+		}
+
+		tenure, ended = endedTenure(lease, consensus.EndPreempted, time.Now(), "")
 		lease.Spec.HolderIdentity = nil
+		delete(lease.Annotations, AddressAnnotation)
+
+		_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+	if err == nil && ended {
+		b.recordTenure(ctx, tenure)
+	}
+	return err
+}
+
+// Transfer hands the Lease to identity, starting a fresh lease term.
+func (b *Backend) Transfer(ctx context.Context, identity string) error {
+	return b.transfer(ctx, identity, nil)
+}
+
+// Claim hands the Lease from from to to if from still holds it, continuing
+// from's tenure. The holder check is repeated against every read, and the
+// update carries the resourceVersion read, so concurrent claims cannot both
+// succeed. It returns consensus.ErrNotHolder if from does not hold the Lease.
+func (b *Backend) Claim(ctx context.Context, from, to string) error {
+	return b.transfer(ctx, to, func(holder string) bool { return holder == from })
+}
+
+// transfer hands the Lease to identity, if allowed accepts the current holder or is nil.
+func (b *Backend) transfer(ctx context.Context, identity string, allowed func(holder string) bool) error {
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+
+	var tenure consensus.Tenure
+	var ended bool
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
+		if err != nil {
+			if allowed != nil && apierrors.IsNotFound(err) {
+				return consensus.ErrNotHolder
+			}
+			return fmt.Errorf("failed to get lease: %w", err)
+		}
+
+		holder := ""
+		if lease.Spec.HolderIdentity != nil {
+			holder = *lease.Spec.HolderIdentity
+		}
+		if allowed != nil && !allowed(holder) {
+			return consensus.ErrNotHolder
+		}
+
+		now := time.Now()
+		ended = false
+		if holder != identity {
+			// A Manager claiming a transfer to its bare identity continues that tenure
+			if claimed, _ := consensus.SplitHolder(identity); lease.Spec.HolderIdentity == nil || claimed != holder {
+				tenure, ended = endedTenure(lease, consensus.EndTransferred, now, identity)
+			}
+			lease.Spec.LeaseTransitions = ptr(transitions(lease) + 1)
+		}
+		delete(lease.Annotations, AddressAnnotation)
+		lease.Spec.HolderIdentity = &identity
+		lease.Spec.AcquireTime = &metav1.MicroTime{Time: now}
+		lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+
+		_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+	if err == nil && ended {
+		b.recordTenure(ctx, tenure)
+	}
+	return err
+}
+
+// GetState returns the checkpoint payload stored in the Lease annotation.
+func (b *Backend) GetState(ctx context.Context) ([]byte, error) {
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+
+	lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get lease for state: %w", err)
+	}
+
+	encoded, ok := lease.Annotations[StateAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	state, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode state annotation: %w", err)
+	}
+
+	return state, nil
+}
+
+// SetState stores a checkpoint payload in the Lease annotation.
+// The update carries the resourceVersion that was read, so a deposed leader
+// racing a takeover re-reads the Lease and is rejected instead of overwriting
+// the new leader's state.
+func (b *Backend) SetState(ctx context.Context, identity string, state []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(state)
+	if len(encoded) > maxStateSize {
+		return fmt.Errorf("%w: %d bytes encoded, limit is %d", ErrStateTooLarge, len(encoded), maxStateSize)
+	}
+
+	return b.updateAnnotation(ctx, identity, StateAnnotation, encoded)
+}
+
+// Enqueue adds holder to the candidate queue annotation, or refreshes its entry.
+// Without a Lease object nobody leads, so there is nothing to wait for.
+func (b *Backend) Enqueue(ctx context.Context, holder string, ttl time.Duration) error {
+	return b.updateQueue(ctx, func(q consensus.Queue) (consensus.Queue, bool) {
+		return q.Enqueue(holder, ttl, time.Now())
+	})
+}
+
+// Dequeue removes holder from the candidate queue annotation.
+func (b *Backend) Dequeue(ctx context.Context, holder string) error {
+	return b.updateQueue(ctx, func(q consensus.Queue) (consensus.Queue, bool) {
+		return q.Remove(holder)
+	})
+}
+
+// QueueHead returns the longest-waiting live candidate, or "" if none is waiting.
+func (b *Backend) QueueHead(ctx context.Context) (string, error) {
+	lease, err := b.client.CoordinationV1().Leases(b.namespace).Get(ctx, b.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get lease: %w", err)
+	}
+
+	return decodeQueue(lease).Head(time.Now()), nil
+}
+
+// updateQueue applies fn to the candidate queue annotation, writing only if it changed.
+func (b *Backend) updateQueue(ctx context.Context, fn func(consensus.Queue) (consensus.Queue, bool)) error {
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get lease: %w", err)
+		}
+
+		queue, changed := fn(decodeQueue(lease))
+		if !changed {
+			return nil
+		}
+
+		if lease.Annotations == nil {
+			lease.Annotations = map[string]string{}
+		}
+		if len(queue) == 0 {
+			delete(lease.Annotations, QueueAnnotation)
+		} else {
+			raw, err := json.Marshal(queue)
+			if err != nil {
+				return err
+			}
+			lease.Annotations[QueueAnnotation] = string(raw)
+		}
+
 		_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// decodeQueue reads the candidate queue annotation. A malformed annotation is
+// treated as an empty queue so it cannot block elections.
+func decodeQueue(lease *coordinationv1.Lease) consensus.Queue {
+	raw := lease.Annotations[QueueAnnotation]
+	if raw == "" {
+		return nil
+	}
+
+	var queue consensus.Queue
+	if err := json.Unmarshal([]byte(raw), &queue); err != nil {
+		return nil
+	}
+	return queue
+}
+
+// updateAnnotation sets an annotation on the Lease if identity holds it.
+// Conflicts are retried so our own concurrent renewals don't fail the write;
+// the holder check is repeated against each fresh read.
+func (b *Backend) updateAnnotation(ctx context.Context, identity, key, value string) error {
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leaseClient.Get(ctx, b.name, metav1.GetOptions{})
 		if err != nil {
-			return fmt.Errorf("failed to release lease: %w", err)
+			return fmt.Errorf("failed to get lease: %w", err)
+		}
+
+		// Verify we're the holder
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity {
+			return consensus.ErrNotHolder
+		}
+
+		if lease.Annotations == nil {
+			lease.Annotations = map[string]string{}
 		}
+		lease.Annotations[key] = value
+
+		_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// leaseDuration returns the configured TTL, or requested if none is set.
+func (b *Backend) leaseDuration(requested time.Duration) time.Duration {
+	if b.ttl > 0 {
+		return b.ttl
 	}
+	return requested
+}
 
-	return nil
+// leaseSeconds converts d to the whole seconds a Lease stores, rounding up
+// so a sub-second duration does not become a lease that has already expired.
+func leaseSeconds(d time.Duration) (int32, error) {
+	if d <= 0 {
+		return 0, fmt.Errorf("%w: lease duration must be positive, got %v", ErrInvalidConfig, d)
+	}
+	seconds := (d + time.Second - 1) / time.Second
+	if seconds > math.MaxInt32 {
+		return 0, fmt.Errorf("%w: lease duration %v too long", ErrInvalidConfig, d)
+	}
+	return int32(seconds), nil
+}
+
+// objectMeta returns the metadata for a new object, applying the configured
+// labels, annotations and owners. labels and annotations override the
+// configured ones.
+func (b *Backend) objectMeta(name string, labels, annotations map[string]string) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       b.namespace,
+		OwnerReferences: slices.Clone(b.owners),
+	}
+	if len(b.labels) > 0 || len(labels) > 0 {
+		meta.Labels = maps.Clone(b.labels)
+		if meta.Labels == nil {
+			meta.Labels = map[string]string{}
+		}
+		maps.Copy(meta.Labels, labels)
+	}
+	if len(b.annotations) > 0 || len(annotations) > 0 {
+		meta.Annotations = maps.Clone(b.annotations)
+		if meta.Annotations == nil {
+			meta.Annotations = map[string]string{}
+		}
+		maps.Copy(meta.Annotations, annotations)
+	}
+	return meta
+}
+
+// transitions returns the Lease's transition count, treating unset as zero.
+func transitions(lease *coordinationv1.Lease) int32 {
+	if lease.Spec.LeaseTransitions == nil {
+		return 0
+	}
+	return *lease.Spec.LeaseTransitions
 }
 
 // ptr is a helper to get a pointer to a value.
@@ -0,0 +1,385 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+)
+
+func TestBackendConformance(t *testing.T) {
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		return NewBackend(fake.NewClientset(), "default", "demo")
+	})
+}
+
+func TestKVConformance(t *testing.T) {
+	consensustest.RunKVSuite(t, func(t *testing.T) consensus.KV {
+		return NewBackend(versionedClientset(), "default", "demo").KV()
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		return NewBackend(fake.NewClientset(), "default", "demo")
+	})
+}
+
+// versionedClientset returns a fake clientset that assigns ConfigMap
+// resourceVersions and rejects stale updates, as the API server does.
+func versionedClientset() *fake.Clientset {
+	client := fake.NewClientset()
+	var revision atomic.Int64
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cm := action.(k8stesting.CreateAction).GetObject().(*corev1.ConfigMap)
+		cm.ResourceVersion = strconv.FormatInt(revision.Add(1), 10)
+		return false, nil, nil
+	})
+	client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cm := action.(k8stesting.UpdateAction).GetObject().(*corev1.ConfigMap)
+		stored, err := client.Tracker().Get(gvr, cm.Namespace, cm.Name)
+		if err == nil && stored.(*corev1.ConfigMap).ResourceVersion != cm.ResourceVersion {
+			return true, nil, apierrors.NewConflict(gvr.GroupResource(), cm.Name, errors.New("stale resourceVersion"))
+		}
+		cm.ResourceVersion = strconv.FormatInt(revision.Add(1), 10)
+		return false, nil, nil
+	})
+	return client
+}
+
+func TestQueueAnnotation(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend(fake.NewClientset(), "default", "demo")
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	for _, holder := range []string{"b", "c"} {
+		if err := b.Enqueue(ctx, holder, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if head, err := b.QueueHead(ctx); err != nil || head != "b" {
+		t.Fatalf("head = %q, err = %v; want b", head, err)
+	}
+
+	// The leader keeps renewing while followers write the annotation
+	if err := b.Renew(ctx, "a", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Dequeue(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if head, err := b.QueueHead(ctx); err != nil || head != "c" {
+		t.Fatalf("head = %q, err = %v; want c", head, err)
+	}
+}
+
+func TestHolderWritesRetryOnConflict(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	b := NewBackend(client, "default", "demo")
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+
+	// Fail the next Lease update, as a follower's concurrent queue write would
+	var conflicts atomic.Int32
+	client.PrependReactor("update", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts.Add(-1) == 0 {
+			gr := coordinationv1.SchemeGroupVersion.WithResource("leases").GroupResource()
+			return true, nil, apierrors.NewConflict(gr, "demo", errors.New("queue annotation changed"))
+		}
+		return false, nil, nil
+	})
+
+	conflicts.Store(1)
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("holder re-acquire after conflict: ok=%v err=%v", ok, err)
+	}
+
+	conflicts.Store(1)
+	if err := b.Release(ctx, "a"); err != nil {
+		t.Fatalf("release after conflict: %v", err)
+	}
+	lease, err := client.CoordinationV1().Leases("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Spec.HolderIdentity != nil {
+		t.Fatalf("holder = %q after release, want none", *lease.Spec.HolderIdentity)
+	}
+}
+
+func TestMembershipLeases(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend(fake.NewClientset(), "default", "demo")
+	other := NewBackend(b.client, "default", "other")
+
+	now := time.Now()
+	for _, member := range []consensus.Member{
+		{Identity: "Pod_A#1f2e", Priority: 3, Version: "v2", LastSeen: now, TTL: time.Minute},
+		{Identity: "pod-b#3c4d", LastSeen: now.Add(-time.Hour), TTL: time.Minute},
+	} {
+		if err := b.Heartbeat(ctx, member); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := other.Heartbeat(ctx, consensus.Member{Identity: "pod-c#5e6f", LastSeen: now, TTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := b.Members(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Identity != "Pod_A#1f2e" || members[0].Priority != 3 || members[0].Version != "v2" {
+		t.Fatalf("members = %+v, want only the live Pod_A of this election", members)
+	}
+
+	// pod-b never left; listing removes its expired Lease
+	if _, err := b.client.CoordinationV1().Leases("default").Get(ctx, b.memberLeaseName("pod-b#3c4d"), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expired member lease still present: %v", err)
+	}
+
+	if err := b.Leave(ctx, "Pod_A#1f2e"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Leave(ctx, "Pod_A#1f2e"); err != nil {
+		t.Fatalf("second leave: %v", err)
+	}
+	if members, err := b.Members(ctx); err != nil || len(members) != 0 {
+		t.Fatalf("members after leave = %+v, err = %v", members, err)
+	}
+}
+
+func TestHistoryConfigMap(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	b := NewBackend(client, "default", "demo")
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.TryAcquire(ctx, "b", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.Transfer(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new backend reads the history back from the ConfigMap
+	history, err := NewBackend(client, "default", "demo").History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 ||
+		history[0].Identity != "a" || history[0].Reason != consensus.EndReleased ||
+		history[1].Identity != "b" || history[1].Reason != consensus.EndTransferred || history[1].Successor != "c" {
+		t.Fatalf("history = %+v, want a released then b transferred to c", history)
+	}
+}
+
+func TestLeaseDurationRoundedUp(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	b := NewBackend(client, "default", "demo")
+
+	if ok, err := b.TryAcquire(ctx, "a", 500*time.Millisecond); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	lease, err := client.CoordinationV1().Leases("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *lease.Spec.LeaseDurationSeconds; got != 1 {
+		t.Fatalf("lease duration = %ds, want 500ms rounded up to 1s", got)
+	}
+	// A fresh sub-second lease is still held
+	if ok, err := b.TryAcquire(ctx, "b", time.Second); err != nil || ok {
+		t.Fatalf("takeover of fresh lease: ok=%v err=%v", ok, err)
+	}
+
+	if err := b.Renew(ctx, "a", 0); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("renew with zero duration: got %v, want ErrInvalidConfig", err)
+	}
+	if _, err := b.TryAcquire(ctx, "a", -time.Second); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("acquire with negative duration: got %v, want ErrInvalidConfig", err)
+	}
+}
+
+func TestCreatedObjectsCarryMetadata(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deploy-uid"}
+	b := NewBackend(client, "default", "demo",
+		WithLabels(map[string]string{"app": "web", MemberOfLabel: "spoofed"}),
+		WithAnnotations(map[string]string{"team": "infra"}),
+		WithOwnerReferences(owner))
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.Heartbeat(ctx, consensus.Member{Identity: "a#1f2e", LastSeen: time.Now(), TTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+
+	leases, err := client.CoordinationV1().Leases("default").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases.Items) != 2 {
+		t.Fatalf("got %d leases, want election and member leases", len(leases.Items))
+	}
+	for _, lease := range leases.Items {
+		if lease.Labels["app"] != "web" || lease.Annotations["team"] != "infra" {
+			t.Errorf("lease %s metadata = %v %v, want configured label and annotation", lease.Name, lease.Labels, lease.Annotations)
+		}
+		if len(lease.OwnerReferences) != 1 || lease.OwnerReferences[0].UID != owner.UID {
+			t.Errorf("lease %s owners = %+v, want the Deployment", lease.Name, lease.OwnerReferences)
+		}
+		if lease.Name != "demo" && lease.Labels[MemberOfLabel] != "demo" {
+			t.Errorf("member lease %s labelled %q, want the backend's label to win", lease.Name, lease.Labels[MemberOfLabel])
+		}
+	}
+}
+
+func TestNewFromEnvRejectsInvalidTTL(t *testing.T) {
+	for _, ttl := range []string{"soon", "0", "-5"} {
+		t.Setenv("CONSENSUS_LEASE_TTL", ttl)
+		if _, err := NewFromEnv("demo"); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("CONSENSUS_LEASE_TTL=%q: got %v, want ErrInvalidConfig", ttl, err)
+		}
+	}
+}
+
+func TestNewFromEnvFallsBackToKubeconfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	raw := `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+current-context: dev
+users:
+- name: dev
+  user:
+    token: secret
+`
+	if err := os.WriteFile(kubeconfig, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBECONFIG", kubeconfig)
+	t.Setenv("POD_NAMESPACE", "dev")
+	t.Setenv("CONSENSUS_LEASE_TTL", "7")
+
+	b, err := NewFromEnv("demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.namespace != "dev" || b.ttl != 7*time.Second {
+		t.Fatalf("namespace = %q, ttl = %v, want dev and 7s", b.namespace, b.ttl)
+	}
+
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	if _, err := NewFromEnv("demo"); !errors.Is(err, ErrK8sConnection) {
+		t.Fatalf("missing kubeconfig: got %v, want ErrK8sConnection", err)
+	}
+}
+
+func TestStateAnnotation(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	b := NewBackend(client, "default", "demo")
+
+	if state, err := b.GetState(ctx); err != nil || state != nil {
+		t.Fatalf("state before any lease = %q, err = %v; want none", state, err)
+	}
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.SetState(ctx, "a", []byte{0, 1, 2, 0xff}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetState(ctx, "b", []byte("intruder")); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("non-holder SetState: got %v, want ErrNotHolder", err)
+	}
+	if err := b.SetState(ctx, "a", make([]byte, maxStateSize)); !errors.Is(err, ErrStateTooLarge) {
+		t.Fatalf("oversized SetState: got %v, want ErrStateTooLarge", err)
+	}
+
+	// An operator hands the lease to b, which sees a's state
+	if err := b.Transfer(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := b.GetState(ctx); err != nil || string(state) != "\x00\x01\x02\xff" {
+		t.Fatalf("state after transfer = %q, err = %v; want a's bytes", state, err)
+	}
+	if err := b.SetState(ctx, "a", []byte("stale")); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("deposed SetState: got %v, want ErrNotHolder", err)
+	}
+	if err := b.SetState(ctx, "b", []byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := b.GetState(ctx); err != nil || string(state) != "next" {
+		t.Fatalf("state = %q, err = %v; want next", state, err)
+	}
+}
+
+func TestClaimRequiresTransferredHolder(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend(fake.NewClientset(), "default", "demo")
+
+	if err := b.Claim(ctx, "pod-a", "pod-a#1f2e"); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("claim without a Lease: got %v, want ErrNotHolder", err)
+	}
+	if ok, err := b.TryAcquire(ctx, "pod-b", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.Transfer(ctx, "pod-a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Claim(ctx, "pod-a", "pod-a#1f2e"); err != nil {
+		t.Fatal(err)
+	}
+	// A second session of the same identity arrives too late
+	if err := b.Claim(ctx, "pod-a", "pod-a#3c4d"); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("second claim: got %v, want ErrNotHolder", err)
+	}
+
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader.Identity != "pod-a#1f2e" {
+		t.Fatalf("holder = %q, want the first claimant", leader.Identity)
+	}
+}
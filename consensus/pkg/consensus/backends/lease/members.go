@@ -0,0 +1,162 @@
+package lease
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MemberOfLabel is set on membership Leases to the name of the election Lease.
+	MemberOfLabel = "consensus.fraser.dev/member-of"
+	// PriorityAnnotation is the membership Lease annotation holding the member's priority.
+	PriorityAnnotation = "consensus.fraser.dev/priority"
+	// VersionAnnotation is the membership Lease annotation holding the member's version.
+	VersionAnnotation = "consensus.fraser.dev/version"
+)
+
+// Heartbeat creates or renews the membership Lease for member.Identity.
+// Each member has its own Lease, labelled with MemberOfLabel, whose holder is
+// the member and whose renew time is its last heartbeat.
+func (b *Backend) Heartbeat(ctx context.Context, member consensus.Member) error {
+	seconds, err := leaseSeconds(member.TTL)
+	if err != nil {
+		return err
+	}
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+	name := b.memberLeaseName(member.Identity)
+
+	lease, err := leaseClient.Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get member lease: %w", err)
+	}
+	exists := err == nil
+	if !exists {
+		lease = &coordinationv1.Lease{ObjectMeta: b.objectMeta(name, nil, nil)}
+	}
+
+	if lease.Labels == nil {
+		lease.Labels = map[string]string{}
+	}
+	lease.Labels[MemberOfLabel] = b.name
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	setAnnotation(lease.Annotations, AddressAnnotation, member.Address)
+	setAnnotation(lease.Annotations, PriorityAnnotation, strconv.Itoa(member.Priority))
+	setAnnotation(lease.Annotations, VersionAnnotation, member.Version)
+
+	lease.Spec.HolderIdentity = &member.Identity
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: member.LastSeen}
+	lease.Spec.LeaseDurationSeconds = ptr(seconds)
+	if lease.Spec.AcquireTime == nil {
+		lease.Spec.AcquireTime = &metav1.MicroTime{Time: member.LastSeen}
+	}
+
+	if exists {
+		_, err = leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+	} else {
+		_, err = leaseClient.Create(ctx, lease, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write member lease: %w", err)
+	}
+	return nil
+}
+
+// Leave deletes holder's membership Lease.
+func (b *Backend) Leave(ctx context.Context, holder string) error {
+	err := b.client.CoordinationV1().Leases(b.namespace).Delete(ctx, b.memberLeaseName(holder), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete member lease: %w", err)
+	}
+	return nil
+}
+
+// Members lists the membership Leases for this election that have not expired.
+// Expired Leases, left by members that stopped without leaving, are deleted
+// as they are found; the delete is conditional on the Lease being unchanged
+// so a member that heartbeats concurrently keeps its record.
+func (b *Backend) Members(ctx context.Context) ([]consensus.Member, error) {
+	list, err := b.client.CoordinationV1().Leases(b.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: MemberOfLabel + "=" + b.name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list member leases: %w", err)
+	}
+
+	leaseClient := b.client.CoordinationV1().Leases(b.namespace)
+	now := time.Now()
+	var members []consensus.Member
+	for _, lease := range list.Items {
+		if lease.Spec.HolderIdentity == nil || lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+			continue
+		}
+
+		priority, _ := strconv.Atoi(lease.Annotations[PriorityAnnotation])
+		member := consensus.Member{
+			Identity: *lease.Spec.HolderIdentity,
+			Address:  lease.Annotations[AddressAnnotation],
+			Priority: priority,
+			Version:  lease.Annotations[VersionAnnotation],
+			LastSeen: lease.Spec.RenewTime.Time,
+			TTL:      time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second,
+		}
+		if member.Expired(now) {
+			_ = leaseClient.Delete(ctx, lease.Name, metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{ResourceVersion: &lease.ResourceVersion},
+			})
+			continue
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// memberLeaseName derives a valid object name for holder's membership Lease.
+func (b *Backend) memberLeaseName(holder string) string {
+	identity, _ := consensus.SplitHolder(holder)
+	return objectName(b.name+"-"+identity, holder)
+}
+
+// objectName derives a valid object name from arbitrary strings. Holder IDs
+// and keys may contain characters Kubernetes rejects, so the name combines a
+// sanitized, truncated readable prefix with a hash of unique.
+func objectName(readable, unique string) string {
+	sum := sha256.Sum256([]byte(unique))
+
+	prefix := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, readable)
+	if len(prefix) > 40 {
+		prefix = prefix[:40]
+	}
+
+	return strings.Trim(prefix, "-") + "-" + hex.EncodeToString(sum[:6])
+}
+
+// setAnnotation sets key to value, removing it when value is empty.
+func setAnnotation(annotations map[string]string, key, value string) {
+	if value == "" {
+		delete(annotations, key)
+		return
+	}
+	annotations[key] = value
+}
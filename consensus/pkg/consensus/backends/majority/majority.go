@@ -0,0 +1,341 @@
+// Package majority implements consensus.Backend on top of several independent
+// backends, in the style of Redlock: leadership is held only while a majority
+// of the underlying stores agree, so no single store is a point of failure.
+package majority
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// DefaultClockDrift is the fraction of the lease duration reserved for clock
+// drift between the stores when computing how long an acquisition is valid.
+const DefaultClockDrift = 0.01
+
+// ErrNoQuorum indicates fewer than a majority of stores granted the lease
+var ErrNoQuorum = errors.New("no majority of stores")
+
+// Backend implements consensus.Backend across N backends.
+type Backend struct {
+	backends []consensus.Backend
+	drift    float64
+}
+
+// NewBackend creates a composite backend over the given backends.
+// Use an odd number of independent stores; with N stores, leadership
+// survives the loss of (N-1)/2 of them.
+func NewBackend(backends ...consensus.Backend) *Backend {
+	return &Backend{
+		backends: backends,
+		drift:    DefaultClockDrift,
+	}
+}
+
+// TryAcquire attempts to acquire or renew leadership on every store in parallel.
+// Leadership is granted only if a majority succeeded and the time spent
+// acquiring left part of the lease valid. Otherwise the stores that were
+// acquired are released so other candidates are not blocked.
+func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	start := time.Now()
+	acquired, errs := b.acquireAll(ctx, identity, leaseDuration)
+
+	if len(acquired) >= b.quorum() && b.validity(start, leaseDuration) > 0 {
+		return true, nil
+	}
+
+	b.releaseFrom(context.WithoutCancel(ctx), acquired, identity)
+
+	// Report errors only when they, rather than other holders, prevented a majority
+	if len(errs) > len(b.backends)-b.quorum() {
+		return false, fmt.Errorf("%w: %w", ErrNoQuorum, errors.Join(errs...))
+	}
+	return false, nil
+}
+
+// Renew extends the lease on every store in parallel and tolerates a minority
+// of failures. Once a majority has renewed, stores that lost the lease (for
+// example after being down) are re-acquired when possible, so a recovered
+// store rejoins the majority.
+func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
+	start := time.Now()
+	renewed, lost, errs := b.renewAll(ctx, identity, leaseDuration)
+
+	if renewed >= b.quorum() && b.validity(start, leaseDuration) > 0 {
+		for _, backend := range lost {
+			_, _ = backend.TryAcquire(ctx, identity, leaseDuration)
+		}
+		return nil
+	}
+
+	if len(errs) == 0 {
+		return fmt.Errorf("%w: %w", ErrNoQuorum, consensus.ErrNotHolder)
+	}
+	return fmt.Errorf("%w: renewed %d of %d: %w", ErrNoQuorum, renewed, len(b.backends), errors.Join(errs...))
+}
+
+// Release gives up leadership on every store.
+func (b *Backend) Release(ctx context.Context, identity string) error {
+	return b.releaseFrom(ctx, b.backends, identity)
+}
+
+// GetLeader returns the holder reported by a majority of stores, or nil if
+// there is no majority. Every store must implement consensus.LeaderReader.
+// The record merges the agreeing stores' views: the highest transitions,
+// the latest renewal and its lease duration, and the first address
+// recorded, so it does not depend on which store answered first.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	counts := make(map[string]int)
+	records := make(map[string]*consensus.LeaderRecord)
+
+	for _, backend := range b.backends {
+		lr, ok := backend.(consensus.LeaderReader)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T does not report the leader", errors.ErrUnsupported, backend)
+		}
+
+		record, err := lr.GetLeader(ctx)
+		if err != nil || record == nil {
+			continue
+		}
+		counts[record.Identity]++
+		merged, ok := records[record.Identity]
+		if !ok {
+			copied := *record
+			records[record.Identity] = &copied
+			continue
+		}
+		merged.Transitions = max(merged.Transitions, record.Transitions)
+		if record.RenewTime.After(merged.RenewTime) {
+			merged.RenewTime = record.RenewTime
+			merged.LeaseDuration = record.LeaseDuration
+		}
+		if merged.Address == "" {
+			merged.Address = record.Address
+		}
+	}
+
+	for identity, n := range counts {
+		if n >= b.quorum() {
+			return records[identity], nil
+		}
+	}
+	return nil, nil
+}
+
+// History returns the tenures recorded by a majority of stores, oldest first.
+// Every store must implement consensus.HistoryReader. Stores record their own
+// terms and times, so tenures are matched by holder and overlapping times; a
+// matched tenure reports the highest term and the earliest acquisition and
+// latest end any store recorded. Stores that fail to answer are skipped and
+// candidates' partial acquisitions, seen by a minority, are left out, so the
+// history is best-effort.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	type recorded struct {
+		store  int
+		tenure consensus.Tenure
+	}
+	type group struct {
+		tenure consensus.Tenure
+		stores map[int]bool
+	}
+
+	var all []recorded
+	for i, backend := range b.backends {
+		hr, ok := backend.(consensus.HistoryReader)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T does not record history", errors.ErrUnsupported, backend)
+		}
+
+		history, err := hr.History(ctx)
+		if err != nil {
+			continue
+		}
+		for _, t := range history {
+			all = append(all, recorded{store: i, tenure: t})
+		}
+	}
+	slices.SortStableFunc(all, func(x, y recorded) int {
+		return x.tenure.AcquireTime.Compare(y.tenure.AcquireTime)
+	})
+
+	var groups []*group
+	for _, r := range all {
+		t := r.tenure
+		i := slices.IndexFunc(groups, func(g *group) bool {
+			return g.tenure.Identity == t.Identity && !t.AcquireTime.After(g.tenure.EndTime)
+		})
+		if i < 0 {
+			groups = append(groups, &group{tenure: t, stores: map[int]bool{r.store: true}})
+			continue
+		}
+
+		g := groups[i]
+		g.stores[r.store] = true
+		g.tenure.Term = max(g.tenure.Term, t.Term)
+		if t.EndTime.After(g.tenure.EndTime) {
+			g.tenure.EndTime = t.EndTime
+			g.tenure.Reason = t.Reason
+			g.tenure.Successor = t.Successor
+		}
+	}
+
+	var history []consensus.Tenure
+	for _, g := range groups {
+		if len(g.stores) >= b.quorum() {
+			history = append(history, g.tenure)
+		}
+	}
+	return history, nil
+}
+
+// SetAddress records identity's address on every store in parallel and
+// succeeds once a majority has recorded it. Every store must implement
+// consensus.AddressAdvertiser.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		written int
+		lost    int
+		errs    []error
+	)
+
+	for _, backend := range b.backends {
+		aa, ok := backend.(consensus.AddressAdvertiser)
+		if !ok {
+			return fmt.Errorf("%w: %T does not store addresses", errors.ErrUnsupported, backend)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := aa.SetAddress(ctx, identity, address)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				written++
+			case errors.Is(err, consensus.ErrNotHolder):
+				lost++
+			default:
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if written >= b.quorum() {
+		return nil
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("%w: %w", ErrNoQuorum, consensus.ErrNotHolder)
+	}
+	return fmt.Errorf("%w: recorded address on %d of %d: %w", ErrNoQuorum, written, len(b.backends), errors.Join(errs...))
+}
+
+// acquireAll calls TryAcquire on every store in parallel and returns the
+// stores that granted the lease along with any errors.
+func (b *Backend) acquireAll(ctx context.Context, identity string, leaseDuration time.Duration) ([]consensus.Backend, []error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		acquired []consensus.Backend
+		errs     []error
+	)
+
+	for _, backend := range b.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := backend.TryAcquire(ctx, identity, leaseDuration)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				acquired = append(acquired, backend)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return acquired, errs
+}
+
+// renewAll renews identity's lease on every store in parallel. It returns
+// how many stores renewed, the stores where identity no longer holds the
+// lease, and the other errors.
+func (b *Backend) renewAll(ctx context.Context, identity string, leaseDuration time.Duration) (int, []consensus.Backend, []error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		renewed int
+		lost    []consensus.Backend
+		errs    []error
+	)
+
+	for _, backend := range b.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := backend.Renew(ctx, identity, leaseDuration)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				renewed++
+			case errors.Is(err, consensus.ErrNotHolder):
+				lost = append(lost, backend)
+			default:
+				errs = append(errs, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return renewed, lost, errs
+}
+
+// releaseFrom calls Release on the given stores in parallel.
+func (b *Backend) releaseFrom(ctx context.Context, backends []consensus.Backend, identity string) error {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+	)
+
+	for _, backend := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := backend.Release(ctx, identity); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// quorum returns the number of stores that must agree.
+func (b *Backend) quorum() int {
+	return len(b.backends)/2 + 1
+}
+
+// validity returns how much of the lease remains after acquiring, less the drift allowance.
+func (b *Backend) validity(start time.Time, leaseDuration time.Duration) time.Duration {
+	drift := time.Duration(float64(leaseDuration)*b.drift) + 2*time.Millisecond
+	return leaseDuration - time.Since(start) - drift
+}
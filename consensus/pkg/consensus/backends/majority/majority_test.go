@@ -0,0 +1,279 @@
+package majority
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+)
+
+// flaky wraps a backend and fails every call while down.
+type flaky struct {
+	consensus.Backend
+	down atomic.Bool
+}
+
+var errDown = errors.New("store down")
+
+func (f *flaky) TryAcquire(ctx context.Context, identity string, d time.Duration) (bool, error) {
+	if f.down.Load() {
+		return false, errDown
+	}
+	return f.Backend.TryAcquire(ctx, identity, d)
+}
+
+func (f *flaky) Renew(ctx context.Context, identity string, d time.Duration) error {
+	if f.down.Load() {
+		return errDown
+	}
+	return f.Backend.Renew(ctx, identity, d)
+}
+
+func (f *flaky) Release(ctx context.Context, identity string) error {
+	if f.down.Load() {
+		return errDown
+	}
+	return f.Backend.Release(ctx, identity)
+}
+
+func (f *flaky) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	if f.down.Load() {
+		return nil, errDown
+	}
+	return f.Backend.(consensus.LeaderReader).GetLeader(ctx)
+}
+
+func (f *flaky) SetAddress(ctx context.Context, identity, address string) error {
+	if f.down.Load() {
+		return errDown
+	}
+	return f.Backend.(consensus.AddressAdvertiser).SetAddress(ctx, identity, address)
+}
+
+func (f *flaky) History(ctx context.Context) ([]consensus.Tenure, error) {
+	if f.down.Load() {
+		return nil, errDown
+	}
+	return f.Backend.(consensus.HistoryReader).History(ctx)
+}
+
+func newStores(t *testing.T, n int) []*flaky {
+	dir := t.TempDir()
+	stores := make([]*flaky, n)
+	for i := range stores {
+		stores[i] = &flaky{Backend: file.NewBackend(filepath.Join(dir, string(rune('a'+i))+".json"))}
+	}
+	return stores
+}
+
+func asBackends(stores []*flaky) []consensus.Backend {
+	backends := make([]consensus.Backend, len(stores))
+	for i, s := range stores {
+		backends[i] = s
+	}
+	return backends
+}
+
+func TestAcquireRequiresMajority(t *testing.T) {
+	ctx := context.Background()
+	stores := newStores(t, 3)
+	b := NewBackend(asBackends(stores)...)
+
+	// Another candidate holds two of the three stores directly
+	for _, s := range stores[:2] {
+		if ok, err := s.TryAcquire(ctx, "other", time.Minute); err != nil || !ok {
+			t.Fatalf("setup: ok=%v err=%v", ok, err)
+		}
+	}
+
+	ok, err := b.TryAcquire(ctx, "a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("acquired with only a minority of stores")
+	}
+
+	// The minority store we did acquire was released again
+	if ok, _ := stores[2].TryAcquire(ctx, "third", time.Minute); !ok {
+		t.Fatal("minority store not released after failed acquisition")
+	}
+}
+
+func TestRenewToleratesMinorityOutage(t *testing.T) {
+	ctx := context.Background()
+	stores := newStores(t, 3)
+	b := NewBackend(asBackends(stores)...)
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+
+	stores[0].down.Store(true)
+	if err := b.Renew(ctx, "a", time.Minute); err != nil {
+		t.Fatalf("renew with one store down: %v", err)
+	}
+
+	stores[1].down.Store(true)
+	if err := b.Renew(ctx, "a", time.Minute); !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("renew with two stores down: got %v, want ErrNoQuorum", err)
+	}
+}
+
+func TestAcquireReportsErrorsWhenStoresDown(t *testing.T) {
+	ctx := context.Background()
+	stores := newStores(t, 3)
+	stores[0].down.Store(true)
+	stores[1].down.Store(true)
+	b := NewBackend(asBackends(stores)...)
+
+	ok, err := b.TryAcquire(ctx, "a", time.Minute)
+	if ok || !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("got ok=%v err=%v, want ErrNoQuorum", ok, err)
+	}
+}
+
+func TestReleaseAllowsTakeover(t *testing.T) {
+	ctx := context.Background()
+	b := NewBackend(asBackends(newStores(t, 5))...)
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := b.TryAcquire(ctx, "b", time.Minute); ok {
+		t.Fatal("second candidate acquired a held lease")
+	}
+
+	if err := b.Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.TryAcquire(ctx, "b", time.Minute); err != nil || !ok {
+		t.Fatalf("takeover: ok=%v err=%v", ok, err)
+	}
+
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Identity != "b" {
+		t.Fatalf("leader = %+v, want b", leader)
+	}
+}
+
+func TestGetLeaderMergesAgreeingStores(t *testing.T) {
+	ctx := context.Background()
+	stores := newStores(t, 3)
+	b := NewBackend(asBackends(stores)...)
+
+	// The last store has seen more transitions than the others
+	for _, identity := range []string{"x", "y"} {
+		if ok, err := stores[2].TryAcquire(ctx, identity, time.Minute); err != nil || !ok {
+			t.Fatalf("setup: ok=%v err=%v", ok, err)
+		}
+		if err := stores[2].Release(ctx, identity); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+
+	// Only the middle store sees the latest renewal
+	time.Sleep(10 * time.Millisecond)
+	if err := stores[1].Renew(ctx, "a", 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := stores[1].GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Identity != "a" || leader.Transitions != 3 {
+		t.Fatalf("leader = %+v, want a with the highest transitions, 3", leader)
+	}
+	if !leader.RenewTime.Equal(renewed.RenewTime) || leader.LeaseDuration != 2*time.Minute {
+		t.Fatalf("leader renewed at %v for %v, want the latest renewal at %v for 2m", leader.RenewTime, leader.LeaseDuration, renewed.RenewTime)
+	}
+}
+
+func TestSetAddressRequiresMajority(t *testing.T) {
+	ctx := context.Background()
+	stores := newStores(t, 3)
+	b := NewBackend(asBackends(stores)...)
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.SetAddress(ctx, "b", "http://b:8080"); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("SetAddress by non-holder: got %v, want ErrNotHolder", err)
+	}
+
+	stores[0].down.Store(true)
+	if err := b.SetAddress(ctx, "a", "http://a:8080"); err != nil {
+		t.Fatalf("SetAddress with one store down: %v", err)
+	}
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Address != "http://a:8080" {
+		t.Fatalf("leader = %+v, want address http://a:8080", leader)
+	}
+
+	stores[1].down.Store(true)
+	if err := b.SetAddress(ctx, "a", "http://a:9090"); !errors.Is(err, ErrNoQuorum) {
+		t.Fatalf("SetAddress with two stores down: got %v, want ErrNoQuorum", err)
+	}
+}
+
+func TestBackendConformance(t *testing.T) {
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		return NewBackend(asBackends(newStores(t, 3))...)
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		return NewBackend(asBackends(newStores(t, 3))...)
+	})
+}
+
+func TestHistoryIgnoresMinorityAcquisitions(t *testing.T) {
+	ctx := context.Background()
+	stores := newStores(t, 3)
+	b := NewBackend(asBackends(stores)...)
+
+	// "other" briefly holds one store directly, as a losing candidate would
+	if ok, err := stores[2].TryAcquire(ctx, "other", time.Minute); err != nil || !ok {
+		t.Fatalf("setup: ok=%v err=%v", ok, err)
+	}
+	if err := stores[2].Release(ctx, "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	stores[0].down.Store(true)
+	if err := b.Release(ctx, "a"); err == nil {
+		t.Fatal("release with one store down: want an error")
+	}
+
+	history, err := b.History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Identity != "a" || history[0].Reason != consensus.EndReleased {
+		t.Fatalf("history = %+v, want only a released", history)
+	}
+}
@@ -0,0 +1,458 @@
+// Package nats implements consensus.Backend on a NATS JetStream key-value bucket.
+//
+// The lease is a single key. Candidates acquire a free key with Create and a
+// lapsed one with a revision-checked Update; the holder renews and releases
+// with revision-checked Updates, so concurrent writers cannot both succeed.
+// Expiry is decided from the renew time stored in the value, and new keys are
+// created with a per-key TTL so a holder that never renews is removed by the
+// server. Watch lets followers react to a release without polling.
+//
+// The highest term handed out and past tenures are kept in a companion
+// "<key>.history" key without a TTL, so terms keep increasing and the history
+// survives the server removing the lease key.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// leaseData is the JSON value stored at the lease key.
+type leaseData struct {
+	Holder        string        `json:"holder"`
+	Address       string        `json:"address,omitempty"`
+	AcquireTime   time.Time     `json:"acquireTime"`
+	RenewTime     time.Time     `json:"renewTime"`
+	LeaseDuration time.Duration `json:"leaseDuration"`
+	Transitions   uint64        `json:"transitions"`
+}
+
+// historyRecord is the JSON value stored at the history key. Transitions is
+// the highest term handed out, and Current is the tenure in progress, so a
+// successor can record it as expired if its holder never released the key.
+type historyRecord struct {
+	Transitions uint64            `json:"transitions"`
+	Current     *consensus.Tenure `json:"current,omitempty"`
+	History     consensus.History `json:"history,omitempty"`
+}
+
+// historyRetries bounds the revision-checked writes to the history key.
+const historyRetries = 5
+
+// expired reports whether the lease is free or has lapsed at now.
+func (d *leaseData) expired(now time.Time) bool {
+	return d.Holder == "" || now.Sub(d.RenewTime) > d.LeaseDuration
+}
+
+// BucketConfig returns a KV bucket configuration suitable for leases: one
+// revision per key, and limit markers enabled so keys can carry a TTL.
+func BucketConfig(bucket string) jetstream.KeyValueConfig {
+	return jetstream.KeyValueConfig{
+		Bucket:         bucket,
+		History:        1,
+		LimitMarkerTTL: time.Minute,
+	}
+}
+
+// Backend implements consensus.Backend using a JetStream KV key.
+type Backend struct {
+	kv           jetstream.KeyValue
+	key          string
+	historyLimit int
+}
+
+// Option configures a Backend.
+type Option func(*Backend)
+
+// WithHistoryLimit sets how many past tenures the history key keeps
+// (default: consensus.DefaultHistoryLimit).
+func WithHistoryLimit(n int) Option {
+	return func(b *Backend) {
+		b.historyLimit = n
+	}
+}
+
+// NewBackend creates a backend electing a leader through key in kv.
+// The bucket must allow per-key TTLs; see BucketConfig.
+func NewBackend(kv jetstream.KeyValue, key string, opts ...Option) *Backend {
+	b := &Backend{
+		kv:  kv,
+		key: key,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// TryAcquire attempts to acquire or renew leadership.
+func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	data, revision, err := b.read(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+
+	if revision != 0 && data.Holder == identity {
+		// Already the holder - renew
+		data.RenewTime = now
+		data.LeaseDuration = leaseDuration
+		if err := b.update(ctx, data, revision); err != nil {
+			if errors.Is(err, jetstream.ErrKeyRevisionMismatch) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+	if revision != 0 && !data.expired(now) {
+		// Someone else holds a valid lease
+		return false, nil
+	}
+
+	// The previous holder's lease lapsed at its expiry, or at the latest now
+	// if the server has removed the key
+	lapsed := now
+	if revision != 0 && data.Holder != "" {
+		lapsed = data.RenewTime.Add(data.LeaseDuration)
+	}
+
+	term, err := b.reserveTerm(ctx, data.Transitions)
+	if err != nil {
+		return false, err
+	}
+	data = &leaseData{
+		Holder:        identity,
+		AcquireTime:   now,
+		RenewTime:     now,
+		LeaseDuration: leaseDuration,
+		Transitions:   term,
+	}
+
+	if revision == 0 {
+		// No key - create it
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return false, err
+		}
+		if _, err := b.kv.Create(ctx, b.key, raw, jetstream.KeyTTL(keyTTL(leaseDuration))); err != nil {
+			if errors.Is(err, jetstream.ErrKeyExists) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to create lease key: %w", err)
+		}
+	} else if err := b.update(ctx, data, revision); err != nil {
+		if errors.Is(err, jetstream.ErrKeyRevisionMismatch) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	b.updateHistory(ctx, func(rec *historyRecord) {
+		if rec.Current != nil && rec.Current.Term < term {
+			rec.endCurrent(consensus.EndExpired, lapsed, identity, b.historyLimit)
+		}
+		rec.Current = &consensus.Tenure{Identity: identity, Term: term, AcquireTime: now}
+	})
+	return true, nil
+}
+
+// Renew extends the current leader's lease.
+func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
+	return b.modify(ctx, identity, func(data *leaseData) {
+		data.RenewTime = time.Now()
+		data.LeaseDuration = leaseDuration
+	})
+}
+
+// Release explicitly gives up leadership.
+func (b *Backend) Release(ctx context.Context, identity string) error {
+	now := time.Now()
+	reason, end := consensus.EndReleased, now
+	var term uint64
+
+	err := b.modify(ctx, identity, func(data *leaseData) {
+		// A lease that had already lapsed is recorded as expired at its expiry
+		if data.expired(now) {
+			reason, end = consensus.EndExpired, data.RenewTime.Add(data.LeaseDuration)
+		}
+		term = data.Transitions
+		data.Holder = ""
+		data.Address = ""
+	})
+	if errors.Is(err, consensus.ErrNotHolder) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	b.updateHistory(ctx, func(rec *historyRecord) {
+		if rec.Current != nil && rec.Current.Term == term {
+			rec.endCurrent(reason, end, "", b.historyLimit)
+		}
+	})
+	return nil
+}
+
+// GetLeader returns the current lease holder, or nil if the lease is free or expired.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	data, _, err := b.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data.expired(time.Now()) {
+		return nil, nil
+	}
+	return data.record(), nil
+}
+
+// SetAddress records the address at which identity can be reached.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	return b.modify(ctx, identity, func(data *leaseData) {
+		data.Address = address
+	})
+}
+
+// History returns the past tenures recorded in the history key, oldest first.
+// A holder whose key the server removed is recorded as expired once a
+// successor acquires the lease.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	rec, _, err := b.readHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rec.History, nil
+}
+
+// Watch streams the lease holder whenever the key changes, starting with the
+// current one. A nil record means the lease is free. The channel is closed
+// when ctx is cancelled.
+func (b *Backend) Watch(ctx context.Context) (<-chan *consensus.LeaderRecord, error) {
+	watcher, err := b.kv.Watch(ctx, b.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch lease key: %w", err)
+	}
+
+	ch := make(chan *consensus.LeaderRecord)
+	go func() {
+		defer close(ch)
+		defer watcher.Stop()
+
+		sent := false
+		for {
+			var entry jetstream.KeyValueEntry
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-watcher.Updates():
+				if !ok {
+					return
+				}
+				entry = e
+			}
+
+			// A nil entry marks the end of the initial values; it only
+			// matters when the key did not exist yet
+			if entry == nil && sent {
+				continue
+			}
+
+			select {
+			case ch <- entryRecord(entry):
+				sent = true
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// modify applies fn to the lease if identity holds it, with a revision-checked update.
+func (b *Backend) modify(ctx context.Context, identity string, fn func(*leaseData)) error {
+	data, revision, err := b.read(ctx)
+	if err != nil {
+		return err
+	}
+	if revision == 0 || data.Holder != identity {
+		return consensus.ErrNotHolder
+	}
+
+	fn(data)
+	if err := b.update(ctx, data, revision); err != nil {
+		if errors.Is(err, jetstream.ErrKeyRevisionMismatch) {
+			// Someone else wrote the key since we read it
+			return fmt.Errorf("%w: %w", consensus.ErrNotHolder, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// reserveTerm records a new term in the history key and returns it. The term
+// is above both floor, the term stored in the lease key, and every term
+// handed out before, and is reserved before the lease is written so it never
+// repeats even if the server removes the lease key. Every conflict means
+// another candidate reserved a term, so contending candidates all finish.
+func (b *Backend) reserveTerm(ctx context.Context, floor uint64) (uint64, error) {
+	for {
+		rec, revision, err := b.readHistory(ctx)
+		if err != nil {
+			// Undecodable history is replaced; floor keeps the term increasing
+			rec = &historyRecord{}
+		}
+
+		term := max(rec.Transitions, floor) + 1
+		rec.Transitions = term
+		err = b.writeHistory(ctx, rec, revision)
+		if err == nil {
+			return term, nil
+		}
+		if !errors.Is(err, jetstream.ErrKeyExists) && !errors.Is(err, jetstream.ErrKeyRevisionMismatch) {
+			return 0, fmt.Errorf("failed to reserve a term: %w", err)
+		}
+	}
+}
+
+// updateHistory applies fn to the history key with a revision-checked write,
+// retrying if another candidate wrote it concurrently. It runs after the
+// change it records and a failure is not reported: the transition has
+// already happened.
+func (b *Backend) updateHistory(ctx context.Context, fn func(*historyRecord)) {
+	for range historyRetries {
+		rec, revision, err := b.readHistory(ctx)
+		if err != nil {
+			// Undecodable history is replaced rather than blocking new records
+			rec = &historyRecord{}
+		}
+
+		fn(rec)
+		err = b.writeHistory(ctx, rec, revision)
+		if !errors.Is(err, jetstream.ErrKeyExists) && !errors.Is(err, jetstream.ErrKeyRevisionMismatch) {
+			return
+		}
+	}
+}
+
+// readHistory returns the history record and its revision, which is zero if
+// the key does not exist.
+func (b *Backend) readHistory(ctx context.Context) (*historyRecord, uint64, error) {
+	entry, err := b.kv.Get(ctx, b.historyKey())
+	if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrKeyDeleted) {
+		return &historyRecord{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read history key: %w", err)
+	}
+
+	var rec historyRecord
+	if err := json.Unmarshal(entry.Value(), &rec); err != nil {
+		return &historyRecord{}, entry.Revision(), fmt.Errorf("failed to decode history key: %w", err)
+	}
+	return &rec, entry.Revision(), nil
+}
+
+// writeHistory writes the history record if the key is still at revision,
+// creating it at revision zero.
+func (b *Backend) writeHistory(ctx context.Context, rec *historyRecord, revision uint64) error {
+	raw, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if revision == 0 {
+		_, err = b.kv.Create(ctx, b.historyKey(), raw)
+	} else {
+		_, err = b.kv.Update(ctx, b.historyKey(), raw, revision)
+	}
+	return err
+}
+
+// historyKey returns the key holding the highest term and past tenures.
+func (b *Backend) historyKey() string {
+	return b.key + ".history"
+}
+
+// endCurrent moves the tenure in progress to the history.
+func (r *historyRecord) endCurrent(reason consensus.EndReason, end time.Time, successor string, limit int) {
+	tenure := *r.Current
+	tenure.EndTime = end
+	tenure.Reason = reason
+	tenure.Successor = successor
+	r.History = r.History.Append(tenure, limit)
+	r.Current = nil
+}
+
+// read returns the lease and its revision. A missing or deleted key is an
+// empty lease at revision 0.
+func (b *Backend) read(ctx context.Context) (*leaseData, uint64, error) {
+	entry, err := b.kv.Get(ctx, b.key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrKeyDeleted) {
+		return &leaseData{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read lease key: %w", err)
+	}
+
+	var data leaseData
+	if err := json.Unmarshal(entry.Value(), &data); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode lease key: %w", err)
+	}
+	return &data, entry.Revision(), nil
+}
+
+// update writes the lease if the key is still at revision.
+func (b *Backend) update(ctx context.Context, data *leaseData, revision uint64) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := b.kv.Update(ctx, b.key, raw, revision); err != nil {
+		if errors.Is(err, jetstream.ErrKeyRevisionMismatch) {
+			return err
+		}
+		return fmt.Errorf("failed to update lease key: %w", err)
+	}
+	return nil
+}
+
+// record converts the lease to a LeaderRecord.
+func (d *leaseData) record() *consensus.LeaderRecord {
+	return &consensus.LeaderRecord{
+		Identity:      d.Holder,
+		Address:       d.Address,
+		AcquireTime:   d.AcquireTime,
+		RenewTime:     d.RenewTime,
+		LeaseDuration: d.LeaseDuration,
+		Transitions:   d.Transitions,
+	}
+}
+
+// entryRecord converts a watched entry to a LeaderRecord, or nil if the lease is free.
+func entryRecord(entry jetstream.KeyValueEntry) *consensus.LeaderRecord {
+	if entry == nil || entry.Operation() != jetstream.KeyValuePut {
+		return nil
+	}
+
+	var data leaseData
+	if err := json.Unmarshal(entry.Value(), &data); err != nil || data.expired(time.Now()) {
+		return nil
+	}
+	return data.record()
+}
+
+// keyTTL is the server-side TTL for a new key. It outlives the lease so the
+// key is only removed once a holder has clearly stopped renewing; JetStream
+// TTLs are whole seconds.
+func keyTTL(leaseDuration time.Duration) time.Duration {
+	return max(time.Second, 2*leaseDuration).Round(time.Second)
+}
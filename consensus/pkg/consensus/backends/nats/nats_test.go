@@ -0,0 +1,172 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+)
+
+// startBucket runs a JetStream-enabled nats-server in-process and returns a lease bucket on it.
+func startBucket(t *testing.T) jetstream.KeyValue {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats-server did not become ready")
+	}
+
+	conn, err := natsgo.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(conn.Close)
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv, err := js.CreateKeyValue(context.Background(), BucketConfig("leases"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kv
+}
+
+func TestBackendConformance(t *testing.T) {
+	kv := startBucket(t)
+	var keys atomic.Int64
+
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		return NewBackend(kv, fmt.Sprintf("test-%d", keys.Add(1)))
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	kv := startBucket(t)
+	var keys atomic.Int64
+
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		return NewBackend(kv, fmt.Sprintf("history-%d", keys.Add(1)))
+	})
+}
+
+func TestTermAndHistorySurviveKeyExpiry(t *testing.T) {
+	kv := startBucket(t)
+	backend := NewBackend(kv, "expiring")
+	ctx := context.Background()
+
+	for _, identity := range []string{"a", "b"} {
+		if ok, err := backend.TryAcquire(ctx, identity, time.Minute); err != nil || !ok {
+			t.Fatalf("acquire %s: ok=%v err=%v", identity, ok, err)
+		}
+		if err := backend.Release(ctx, identity); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only a newly created key carries a TTL
+	if err := kv.Purge(ctx, "expiring"); err != nil {
+		t.Fatal(err)
+	}
+
+	// c creates the key with a one-second TTL and never renews it
+	if ok, err := backend.TryAcquire(ctx, "c", 500*time.Millisecond); err != nil || !ok {
+		t.Fatalf("acquire c: ok=%v err=%v", ok, err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, err := kv.Get(ctx, "expiring")
+		if errors.Is(err, jetstream.ErrKeyNotFound) || errors.Is(err, jetstream.ErrKeyDeleted) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lease key not removed by its TTL: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if ok, err := backend.TryAcquire(ctx, "d", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire d: ok=%v err=%v", ok, err)
+	}
+	leader, err := backend.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Identity != "d" || leader.Transitions != 4 {
+		t.Fatalf("leader = %+v, want d at term 4", leader)
+	}
+
+	history, err := backend.History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history = %+v, want three tenures", history)
+	}
+	for i, want := range []string{"a", "b", "c"} {
+		if history[i].Identity != want || history[i].Term != uint64(i+1) {
+			t.Fatalf("tenure %d = %+v, want %s at term %d", i, history[i], want, i+1)
+		}
+	}
+	if c := history[2]; c.Reason != consensus.EndExpired || c.Successor != "d" {
+		t.Fatalf("tenure = %+v, want c expired with successor d", c)
+	}
+}
+
+func TestFollowerAcquiresOnRelease(t *testing.T) {
+	backend := NewBackend(startBucket(t), "leader")
+
+	config := func(identity string) consensus.Config {
+		return consensus.Config{
+			Identity:      identity,
+			LeaseDuration: time.Minute,
+			RenewInterval: 10 * time.Second,
+			RetryInterval: 20 * time.Millisecond,
+		}
+	}
+
+	first := consensus.NewManager(backend, config("a"))
+	firstLease := first.Start(context.Background())
+	defer first.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := firstLease.WaitForLeadership(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The follower only retries once a minute, so it can only win quickly through the watch
+	followerConfig := config("b")
+	followerConfig.RetryInterval = time.Minute
+	second := consensus.NewManager(backend, followerConfig)
+	secondLease := second.Start(context.Background())
+	defer second.Stop()
+
+	time.Sleep(100 * time.Millisecond)
+	first.StepDown()
+
+	if err := secondLease.WaitForLeadership(ctx); err != nil {
+		t.Fatalf("follower did not take over after release: %v", err)
+	}
+}
@@ -0,0 +1,404 @@
+// Package objectstore implements consensus.Backend on a single object in an
+// object store that supports conditional writes, such as S3.
+//
+// The object holds the same JSON lease record as backends/file. A free lease
+// is created with If-None-Match and every later change is written with
+// If-Match on the ETag that was read, so two candidates cannot both win.
+//
+// A copy of the lease is kept at <key>.bak whenever the holder, history or
+// state changes. An object that cannot be decoded is respected for a grace
+// period, then recovered from that copy and overwritten conditionally on its
+// ETag, so fencing terms never go backwards.
+package objectstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+var (
+	// ErrNotFound is returned by Storage.Get when the object does not exist.
+	ErrNotFound = errors.New("object not found")
+	// ErrPreconditionFailed is returned by Storage puts when the object changed
+	// (or, for PutIfAbsent, already exists).
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// formatVersion matches the lease file schema of backends/file.
+const formatVersion = 1
+
+// DefaultCorruptionGracePeriod is how long an undecodable lease object is
+// respected before it is treated as an expired lease.
+const DefaultCorruptionGracePeriod = time.Minute
+
+// backupRetries bounds how often a backup write is retried after losing a race.
+const backupRetries = 3
+
+// Storage is the minimal object store API the backend needs.
+type Storage interface {
+	// Get returns the object's content and ETag, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, string, error)
+
+	// PutIfAbsent creates the object only if it does not exist (If-None-Match: *).
+	// Returns ErrPreconditionFailed if it does.
+	PutIfAbsent(ctx context.Context, key string, data []byte) error
+
+	// PutIfMatch replaces the object only if its ETag is still etag (If-Match).
+	// Returns ErrPreconditionFailed if it changed.
+	PutIfMatch(ctx context.Context, key string, data []byte, etag string) error
+}
+
+// leaseData is the JSON lease record, identical to the backends/file format.
+type leaseData struct {
+	Version       int               `json:"version"`
+	Holder        string            `json:"holder"`
+	Address       string            `json:"address,omitempty"`
+	AcquireTime   time.Time         `json:"acquireTime"`
+	RenewTime     time.Time         `json:"renewTime"`
+	LeaseDuration time.Duration     `json:"leaseDuration"`
+	Transitions   uint64            `json:"transitions"`
+	State         []byte            `json:"state,omitempty"`
+	History       consensus.History `json:"history,omitempty"`
+}
+
+// expired reports whether the lease is free or has lapsed at now.
+func (d *leaseData) expired(now time.Time) bool {
+	return d.Holder == "" || now.Sub(d.RenewTime) > d.LeaseDuration
+}
+
+// Backend implements consensus.Backend using one object.
+type Backend struct {
+	storage         Storage
+	key             string
+	historyLimit    int
+	corruptionGrace time.Duration
+
+	// corruptETag is the undecodable object first seen at corruptSince
+	mu           sync.Mutex
+	corruptETag  string
+	corruptSince time.Time
+}
+
+// Option configures a Backend.
+type Option func(*Backend)
+
+// WithHistoryLimit sets how many past tenures the lease object keeps
+// (default: consensus.DefaultHistoryLimit).
+func WithHistoryLimit(n int) Option {
+	return func(b *Backend) {
+		b.historyLimit = n
+	}
+}
+
+// WithCorruptionGracePeriod sets how long an undecodable lease object is
+// respected before it is treated as expired (default: DefaultCorruptionGracePeriod).
+// It should be longer than the lease duration in use.
+func WithCorruptionGracePeriod(d time.Duration) Option {
+	return func(b *Backend) {
+		b.corruptionGrace = d
+	}
+}
+
+// NewBackend creates a backend storing the lease at key in storage.
+func NewBackend(storage Storage, key string, opts ...Option) *Backend {
+	b := &Backend{
+		storage:         storage,
+		key:             key,
+		corruptionGrace: DefaultCorruptionGracePeriod,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// TryAcquire attempts to acquire or renew leadership.
+func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	data, etag, err := b.read(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	renew := data.Holder == identity
+
+	switch {
+	case renew:
+		// Already the holder - renew
+	case data.expired(now):
+		b.endTenure(data, consensus.EndExpired, now, identity)
+		data.Transitions++
+		data.Holder = identity
+		data.Address = ""
+		data.AcquireTime = now
+	default:
+		// Someone else holds a valid lease
+		return false, nil
+	}
+
+	data.RenewTime = now
+	data.LeaseDuration = leaseDuration
+	if err := b.write(ctx, data, etag); err != nil {
+		if errors.Is(err, ErrPreconditionFailed) {
+			// Another candidate wrote first
+			return false, nil
+		}
+		return false, err
+	}
+	if !renew {
+		b.backup(ctx, data)
+	}
+	return true, nil
+}
+
+// Renew extends the current leader's lease.
+// Renewals are not backed up, since a recovered lease has lapsed anyway.
+func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
+	return b.modify(ctx, identity, false, func(data *leaseData) {
+		data.RenewTime = time.Now()
+		data.LeaseDuration = leaseDuration
+	})
+}
+
+// Release explicitly gives up leadership.
+func (b *Backend) Release(ctx context.Context, identity string) error {
+	err := b.modify(ctx, identity, true, func(data *leaseData) {
+		b.endTenure(data, consensus.EndReleased, time.Now(), "")
+		data.Holder = ""
+		data.Address = ""
+	})
+	if errors.Is(err, consensus.ErrNotHolder) {
+		return nil
+	}
+	return err
+}
+
+// GetLeader returns the current lease holder, or nil if the lease is free or expired.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	data, _, err := b.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data.expired(time.Now()) {
+		return nil, nil
+	}
+
+	return &consensus.LeaderRecord{
+		Identity:      data.Holder,
+		Address:       data.Address,
+		AcquireTime:   data.AcquireTime,
+		RenewTime:     data.RenewTime,
+		LeaseDuration: data.LeaseDuration,
+		Transitions:   data.Transitions,
+	}, nil
+}
+
+// SetAddress records the address at which identity can be reached.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	return b.modify(ctx, identity, true, func(data *leaseData) {
+		data.Address = address
+	})
+}
+
+// GetState returns the state payload stored with the lease.
+func (b *Backend) GetState(ctx context.Context) ([]byte, error) {
+	data, _, err := b.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.State, nil
+}
+
+// SetState stores the payload in the lease object if identity holds the lease.
+func (b *Backend) SetState(ctx context.Context, identity string, state []byte) error {
+	return b.modify(ctx, identity, true, func(data *leaseData) {
+		data.State = state
+	})
+}
+
+// History returns the past tenures recorded in the lease object, oldest first.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	data, _, err := b.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return data.History, nil
+}
+
+// endTenure appends the current holder's tenure to the history. A lease that
+// had already lapsed is recorded as expired at its expiry, whatever ended it.
+func (b *Backend) endTenure(data *leaseData, reason consensus.EndReason, now time.Time, successor string) {
+	if data.Holder == "" {
+		return
+	}
+
+	end := now
+	if data.expired(now) {
+		reason = consensus.EndExpired
+		end = data.RenewTime.Add(data.LeaseDuration)
+	}
+	data.History = data.History.Append(consensus.Tenure{
+		Identity:    data.Holder,
+		Term:        data.Transitions,
+		AcquireTime: data.AcquireTime,
+		EndTime:     end,
+		Reason:      reason,
+		Successor:   successor,
+	}, b.historyLimit)
+}
+
+// modify applies fn to the lease if identity holds it, conditional on the
+// ETag read, and then updates the backup if backup is set.
+func (b *Backend) modify(ctx context.Context, identity string, backup bool, fn func(*leaseData)) error {
+	data, etag, err := b.read(ctx)
+	if err != nil {
+		return err
+	}
+	if data.Holder != identity {
+		return consensus.ErrNotHolder
+	}
+
+	fn(data)
+	if err := b.write(ctx, data, etag); err != nil {
+		if errors.Is(err, ErrPreconditionFailed) {
+			// The object changed since we read it
+			return fmt.Errorf("%w: %w", consensus.ErrNotHolder, err)
+		}
+		return err
+	}
+	if backup {
+		b.backup(ctx, data)
+	}
+	return nil
+}
+
+// read returns the lease and its ETag. A missing object is an empty lease
+// with no ETag. An object that cannot be decoded (e.g. written by a broken
+// client) is recovered from the backup once this backend has seen it
+// unchanged for the corruption grace period, and is returned with its own
+// ETag so the next write replaces it only if it is still the same object.
+func (b *Backend) read(ctx context.Context) (*leaseData, string, error) {
+	raw, etag, err := b.storage.Get(ctx, b.key)
+	if errors.Is(err, ErrNotFound) {
+		return &leaseData{}, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read lease object: %w", err)
+	}
+
+	var data leaseData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		if b.corruptFor(etag) > b.corruptionGrace {
+			return b.recoverLease(ctx), etag, nil
+		}
+		return nil, "", fmt.Errorf("failed to decode lease object: %w", err)
+	}
+	if data.Version > formatVersion {
+		return nil, "", fmt.Errorf("lease object has format version %d, newer than supported version %d", data.Version, formatVersion)
+	}
+	return &data, etag, nil
+}
+
+// write stores the lease, creating the object if etag is empty.
+func (b *Backend) write(ctx context.Context, data *leaseData, etag string) error {
+	data.Version = formatVersion
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if etag == "" {
+		err = b.storage.PutIfAbsent(ctx, b.key, raw)
+	} else {
+		err = b.storage.PutIfMatch(ctx, b.key, raw, etag)
+	}
+	if err != nil && !errors.Is(err, ErrPreconditionFailed) {
+		return fmt.Errorf("failed to write lease object: %w", err)
+	}
+	return err
+}
+
+// corruptFor returns how long this backend has seen the undecodable object
+// with etag. Seeing a different object restarts the clock.
+func (b *Backend) corruptFor(etag string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.corruptETag != etag {
+		b.corruptETag = etag
+		b.corruptSince = time.Now()
+	}
+	return time.Since(b.corruptSince)
+}
+
+// recoverLease returns the last good lease from the backup object. Its
+// holder has long lapsed, since the grace period outlives the lease, so the
+// next candidate takes over and records it as expired. The term is advanced
+// past any the lost write may have handed out, so fencing terms never go
+// backwards. Without a usable backup the lease starts empty.
+func (b *Backend) recoverLease(ctx context.Context) *leaseData {
+	data, _, err := b.readBackup(ctx)
+	if err != nil || data == nil {
+		return &leaseData{}
+	}
+	data.Transitions++
+	return data
+}
+
+// backup copies the lease to the backup object. It is best effort: a failed
+// backup leaves the previous copy, which recovery allows for. A backup is
+// never replaced by one with an older term.
+func (b *Backend) backup(ctx context.Context, data *leaseData) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	for i := 0; i < backupRetries; i++ {
+		current, etag, err := b.readBackup(ctx)
+		if err != nil {
+			return
+		}
+		if current != nil && current.Transitions > data.Transitions {
+			return
+		}
+
+		if etag == "" {
+			err = b.storage.PutIfAbsent(ctx, b.backupKey(), raw)
+		} else {
+			err = b.storage.PutIfMatch(ctx, b.backupKey(), raw, etag)
+		}
+		if !errors.Is(err, ErrPreconditionFailed) {
+			return
+		}
+	}
+}
+
+// readBackup returns the backup lease and its ETag, or nil if there is none.
+// An undecodable backup is returned as nil with its ETag, so it is replaced.
+func (b *Backend) readBackup(ctx context.Context) (*leaseData, string, error) {
+	raw, etag, err := b.storage.Get(ctx, b.backupKey())
+	if errors.Is(err, ErrNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var data leaseData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Version > formatVersion {
+		return nil, etag, nil
+	}
+	return &data, etag, nil
+}
+
+// backupKey returns the key of the last good copy of the lease object.
+func (b *Backend) backupKey() string {
+	return b.key + ".bak"
+}
@@ -0,0 +1,225 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+)
+
+// fakeS3 is an in-memory S3 endpoint supporting GET and conditional PUT.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, exists := f.objects[r.URL.Path]
+	etag := func(b []byte) string {
+		sum := md5.Sum(b)
+		return `"` + hex.EncodeToString(sum[:]) + `"`
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", etag(data))
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+
+	case http.MethodPut:
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			s3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != etag(data)) {
+			s3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[r.URL.Path] = body
+		w.Header().Set("ETag", etag(body))
+
+	default:
+		s3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// newStorage returns S3Storage pointed at a fresh fake S3 server.
+func newStorage(t *testing.T) *S3Storage {
+	t.Helper()
+
+	srv := httptest.NewServer(&fakeS3{objects: map[string][]byte{}})
+	t.Cleanup(srv.Close)
+
+	endpoint, _ := url.Parse(srv.URL)
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4("", "", ""),
+		Region: "us-east-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewS3Storage(client, "leases")
+}
+
+func TestBackendConformance(t *testing.T) {
+	storage := newStorage(t)
+	var keys atomic.Int64
+
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		return NewBackend(storage, fmt.Sprintf("test-%d.json", keys.Add(1)))
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	storage := newStorage(t)
+	var keys atomic.Int64
+
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		return NewBackend(storage, fmt.Sprintf("history-%d.json", keys.Add(1)))
+	})
+}
+
+func TestObjectUsesFileFormat(t *testing.T) {
+	storage := newStorage(t)
+	b := NewBackend(storage, "lease.json")
+	ctx := context.Background()
+
+	if ok, err := b.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+
+	raw, _, err := storage.Get(ctx, "lease.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"version":1`, `"holder":"a"`, `"transitions":1`} {
+		if !strings.Contains(string(raw), field) {
+			t.Fatalf("object %s missing %s", raw, field)
+		}
+	}
+}
+
+func TestCorruptObjectRecoversAfterGracePeriod(t *testing.T) {
+	storage := newStorage(t)
+	b := NewBackend(storage, "lease.json", WithCorruptionGracePeriod(100*time.Millisecond))
+	ctx := context.Background()
+
+	// a and b each hold the lease once, so the backup is at term 2
+	for _, identity := range []string{"a", "b"} {
+		if ok, err := b.TryAcquire(ctx, identity, 10*time.Millisecond); err != nil || !ok {
+			t.Fatalf("acquire %s: ok=%v err=%v", identity, ok, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	_, etag, err := storage.Get(ctx, "lease.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.PutIfMatch(ctx, "lease.json", []byte(`{"holder":`), etag); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.TryAcquire(ctx, "c", time.Minute); err == nil {
+		t.Fatal("acquire succeeded on a corrupt object within the grace period")
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	if ok, err := b.TryAcquire(ctx, "c", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire after grace period: ok=%v err=%v", ok, err)
+	}
+
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lost write may have handed out term 3, so c must be past it
+	if leader == nil || leader.Identity != "c" || leader.Transitions != 4 {
+		t.Fatalf("leader = %+v, want c at term 4", leader)
+	}
+
+	history, err := b.History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Identity != "b" || history[1].Successor != "c" {
+		t.Fatalf("history = %+v, want a and b with successor c", history)
+	}
+}
+
+func TestCorruptObjectOverwriteIsConditional(t *testing.T) {
+	storage := newStorage(t)
+	b := NewBackend(storage, "lease.json", WithCorruptionGracePeriod(50*time.Millisecond))
+	ctx := context.Background()
+
+	if err := storage.PutIfAbsent(ctx, "lease.json", []byte("garbage")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.TryAcquire(ctx, "a", time.Minute); err == nil {
+		t.Fatal("acquire succeeded on a corrupt object within the grace period")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// A different undecodable object restarts the grace period
+	_, etag, err := storage.Get(ctx, "lease.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.PutIfMatch(ctx, "lease.json", []byte("other garbage"), etag); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.TryAcquire(ctx, "a", time.Minute); err == nil {
+		t.Fatal("acquire succeeded on a replaced corrupt object")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Someone repairs the object between our read and our write
+	data, etag, err := b.read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.PutIfMatch(ctx, "lease.json", []byte(`{"version":1}`), etag); err != nil {
+		t.Fatal(err)
+	}
+	data.Holder = "a"
+	if err := b.write(ctx, data, etag); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("overwrite of a replaced object: err = %v, want ErrPreconditionFailed", err)
+	}
+}
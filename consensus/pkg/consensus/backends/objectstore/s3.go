@@ -0,0 +1,84 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// S3Storage implements Storage on an S3-compatible bucket.
+// The service must support conditional PUTs (If-None-Match and If-Match),
+// as AWS S3 and MinIO do.
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+var _ Storage = (*S3Storage)(nil)
+
+// NewS3Storage creates a Storage for bucket.
+func NewS3Storage(client *minio.Client, bucket string) *S3Storage {
+	return &S3Storage{
+		client: client,
+		bucket: bucket,
+	}
+}
+
+// Get returns the object's content and ETag, or ErrNotFound.
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, string, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, "", mapS3Error(err)
+	}
+	defer obj.Close()
+
+	info, err := obj.Stat()
+	if err != nil {
+		return nil, "", mapS3Error(err)
+	}
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, "", mapS3Error(err)
+	}
+	return data, info.ETag, nil
+}
+
+// PutIfAbsent creates the object only if it does not exist.
+func (s *S3Storage) PutIfAbsent(ctx context.Context, key string, data []byte) error {
+	opts := minio.PutObjectOptions{ContentType: "application/json"}
+	opts.SetMatchETagExcept("*")
+	return s.put(ctx, key, data, opts)
+}
+
+// PutIfMatch replaces the object only if its ETag is still etag.
+func (s *S3Storage) PutIfMatch(ctx context.Context, key string, data []byte, etag string) error {
+	opts := minio.PutObjectOptions{ContentType: "application/json"}
+	opts.SetMatchETag(etag)
+	return s.put(ctx, key, data, opts)
+}
+
+func (s *S3Storage) put(ctx context.Context, key string, data []byte, opts minio.PutObjectOptions) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), opts)
+	return mapS3Error(err)
+}
+
+// mapS3Error maps S3 error responses to the Storage errors.
+func mapS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	resp := minio.ToErrorResponse(err)
+	switch {
+	case resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusPreconditionFailed,
+		// S3 reports a conditional write racing another as a conflict
+		resp.StatusCode == http.StatusConflict:
+		return ErrPreconditionFailed
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package quorum
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	votePath    = "/quorum/vote"
+	releasePath = "/quorum/release"
+)
+
+// Handler returns the HTTP handler that serves this peer's voter.
+// Mount it on the address other peers use to reach this one.
+func (b *Backend) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST "+votePath, func(w http.ResponseWriter, r *http.Request) {
+		var req VoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(b.HandleVote(req))
+	})
+
+	mux.HandleFunc("POST "+releasePath, func(w http.ResponseWriter, r *http.Request) {
+		var req ReleaseRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b.HandleRelease(req)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+// HTTPTransport reaches peers over HTTP. Peer addresses are base URLs.
+type HTTPTransport struct {
+	client *http.Client
+}
+
+// NewHTTPTransport creates a transport using client, or http.DefaultClient if nil.
+func NewHTTPTransport(client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPTransport{client: client}
+}
+
+// RequestVote sends a vote request to peer.
+func (t *HTTPTransport) RequestVote(ctx context.Context, peer string, req VoteRequest) (VoteResponse, error) {
+	var resp VoteResponse
+	if err := t.post(ctx, peer+votePath, req, &resp); err != nil {
+		return VoteResponse{}, err
+	}
+	return resp, nil
+}
+
+// Release sends a release request to peer.
+func (t *HTTPTransport) Release(ctx context.Context, peer string, req ReleaseRequest) error {
+	return t.post(ctx, peer+releasePath, req, nil)
+}
+
+// post sends body as JSON and decodes the response into out if non-nil.
+func (t *HTTPTransport) post(ctx context.Context, url string, body, out any) error {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to reach peer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("peer returned %s", resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,471 @@
+// Package quorum implements consensus.Backend without an external store.
+// A fixed set of peers vote for candidates: a candidate holds the lease for a
+// term once a majority of peers have promised it their vote for the lease duration.
+package quorum
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// VoteRequest asks a peer to promise its vote to a candidate for a term.
+type VoteRequest struct {
+	Candidate     string        `json:"candidate"`
+	Term          uint64        `json:"term"`
+	LeaseDuration time.Duration `json:"leaseDuration"`
+	Address       string        `json:"address,omitempty"` // Address advertised by the candidate, if any
+}
+
+// VoteResponse is a peer's answer to a VoteRequest.
+type VoteResponse struct {
+	Granted bool   `json:"granted"`
+	Term    uint64 `json:"term"`             // Highest term the peer has granted
+	Holder  string `json:"holder,omitempty"` // Candidate holding the peer's vote, if any
+}
+
+// ReleaseRequest asks a peer to drop its promise to a candidate.
+type ReleaseRequest struct {
+	Candidate string `json:"candidate"`
+	Term      uint64 `json:"term"`
+	Abandoned bool   `json:"abandoned,omitempty"` // The candidate never held the term, so no tenure ended
+}
+
+// Transport delivers requests to peers.
+type Transport interface {
+	RequestVote(ctx context.Context, peer string, req VoteRequest) (VoteResponse, error)
+	Release(ctx context.Context, peer string, req ReleaseRequest) error
+}
+
+// DefaultClockDrift is the fraction of the lease duration subtracted from a
+// candidate's own view of its lease, so it stops leading before any voter's
+// promise lapses.
+const DefaultClockDrift = 0.01
+
+// Option configures a Backend.
+type Option func(*Backend)
+
+// WithTransport sets the transport used to reach peers (default: HTTP).
+func WithTransport(transport Transport) Option {
+	return func(b *Backend) {
+		b.transport = transport
+	}
+}
+
+// WithElectionJitter sets the maximum random delay before a candidate
+// campaigns for a free lease (default: 50ms). Jitter breaks up split votes
+// when many candidates retry on the same interval.
+func WithElectionJitter(d time.Duration) Option {
+	return func(b *Backend) {
+		b.jitter = d
+	}
+}
+
+// WithHistoryLimit sets how many past tenures each peer's voter keeps
+// (default: consensus.DefaultHistoryLimit).
+func WithHistoryLimit(n int) Option {
+	return func(b *Backend) {
+		b.historyLimit = n
+	}
+}
+
+// promise is a voter's commitment to a candidate.
+type promise struct {
+	candidate string
+	address   string
+	term      uint64
+	granted   time.Time
+	expires   time.Time
+}
+
+// Backend implements consensus.Backend by collecting votes from a majority of peers.
+// Every peer runs a Backend and serves Handler() so other peers can reach its voter.
+type Backend struct {
+	self      string
+	peers     []string
+	transport Transport
+	jitter    time.Duration
+
+	// Voter state
+	voteMu       sync.Mutex
+	term         uint64
+	promise      promise
+	history      consensus.History
+	historyLimit int
+
+	// Candidate state
+	mu          sync.Mutex
+	holder      string
+	holderTerm  uint64
+	holderLease time.Duration
+	address     string
+	leaseUntil  time.Time
+	seenTerm    uint64
+}
+
+// NewBackend creates a quorum backend for the peer at self.
+// peers lists every member of the group by the address used to reach it;
+// self is added if missing. With the default HTTP transport, addresses are base URLs.
+func NewBackend(self string, peers []string, opts ...Option) *Backend {
+	peers = slices.Clone(peers)
+	if !slices.Contains(peers, self) {
+		peers = append(peers, self)
+	}
+
+	b := &Backend{
+		self:      self,
+		peers:     peers,
+		transport: NewHTTPTransport(nil),
+		jitter:    50 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// TryAcquire attempts to acquire or renew leadership.
+func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	b.voteMu.Lock()
+	voterTerm := b.term
+	b.voteMu.Unlock()
+
+	b.mu.Lock()
+	holding := b.holder == identity && time.Now().Before(b.leaseUntil)
+	term := b.holderTerm
+	if !holding {
+		term = max(b.seenTerm, b.holderTerm, voterTerm) + 1
+	}
+	b.mu.Unlock()
+
+	if !holding {
+		// Don't campaign against a candidate our own voter has promised to
+		if holder := b.localHolder(); holder != "" && holder != identity {
+			return false, nil
+		}
+
+		if b.jitter > 0 {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(rand.N(b.jitter)):
+			}
+		}
+	}
+
+	return b.campaign(ctx, identity, term, leaseDuration)
+}
+
+// Renew extends the current leader's lease.
+func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
+	b.mu.Lock()
+	holding := b.holder == identity && time.Now().Before(b.leaseUntil)
+	term := b.holderTerm
+	b.mu.Unlock()
+
+	if !holding {
+		return consensus.ErrNotHolder
+	}
+
+	ok, err := b.campaign(ctx, identity, term, leaseDuration)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("failed to renew: no majority for term %d", term)
+	}
+
+	return nil
+}
+
+// Release explicitly gives up leadership.
+func (b *Backend) Release(ctx context.Context, identity string) error {
+	b.mu.Lock()
+	if b.holder != identity {
+		b.mu.Unlock()
+		return nil
+	}
+	term := b.holderTerm
+	b.holder = ""
+	b.address = ""
+	b.leaseUntil = time.Time{}
+	b.mu.Unlock()
+
+	b.releaseFrom(ctx, b.peers, ReleaseRequest{Candidate: identity, Term: term})
+	return nil
+}
+
+// SetAddress records the address at which identity serves requests. The
+// address rides on every vote request, so voters report it from GetLeader;
+// a changed address is pushed to them straight away by renewing the term.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	b.mu.Lock()
+	holding := b.holder == identity && time.Now().Before(b.leaseUntil)
+	changed := b.address != address
+	leaseDuration := b.holderLease
+	if holding {
+		b.address = address
+	}
+	b.mu.Unlock()
+
+	if !holding {
+		return consensus.ErrNotHolder
+	}
+	if !changed {
+		return nil
+	}
+	return b.Renew(ctx, identity, leaseDuration)
+}
+
+// GetLeader returns the candidate this peer's voter has promised its vote to.
+// This is the local view; during an election it may name a candidate that
+// does not go on to win a majority.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	if b.promise.candidate == "" || !time.Now().Before(b.promise.expires) {
+		return nil, nil
+	}
+
+	return &consensus.LeaderRecord{
+		Identity:      b.promise.candidate,
+		Address:       b.promise.address,
+		LeaseDuration: time.Until(b.promise.expires),
+		RenewTime:     time.Now(),
+		Transitions:   b.promise.term,
+	}, nil
+}
+
+// History returns the tenures this peer's voter has seen end, oldest first.
+// Like GetLeader it is the local view: it is kept in memory, so it starts
+// empty when the peer restarts, and a voter that missed a leader's votes
+// does not record its tenure. A holder whose promise lapsed is recorded as
+// expired, with the candidate that next won the vote as its successor.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	history := slices.Clone(b.history)
+	if b.promise.candidate != "" && !time.Now().Before(b.promise.expires) {
+		// The lapsed promise is recorded, with its successor, once replaced
+		history = history.Append(b.promise.tenure(consensus.EndExpired, b.promise.expires, ""), b.historyLimit)
+	}
+	return history, nil
+}
+
+// Term returns the term of the lease held through this peer, or 0 if none.
+// Terms increase with every new leader and can be used as fencing tokens.
+func (b *Backend) Term() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.holder == "" || !time.Now().Before(b.leaseUntil) {
+		return 0
+	}
+	return b.holderTerm
+}
+
+// campaign requests votes for identity at term from every peer in parallel.
+// The lease is held if a majority grants before it would already have expired.
+func (b *Backend) campaign(ctx context.Context, identity string, term uint64, leaseDuration time.Duration) (bool, error) {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, leaseDuration/2)
+	defer cancel()
+
+	b.mu.Lock()
+	var address string
+	if b.holder == identity {
+		address = b.address
+	}
+	b.mu.Unlock()
+
+	req := VoteRequest{Candidate: identity, Term: term, LeaseDuration: leaseDuration, Address: address}
+
+	type result struct {
+		peer string
+		resp VoteResponse
+		err  error
+	}
+	results := make(chan result, len(b.peers))
+	for _, peer := range b.peers {
+		go func() {
+			resp, err := b.requestVote(ctx, peer, req)
+			results <- result{peer: peer, resp: resp, err: err}
+		}()
+	}
+
+	var granted []string
+	var seen uint64
+	for range b.peers {
+		r := <-results
+		if r.err != nil {
+			continue
+		}
+		seen = max(seen, r.resp.Term)
+		if r.resp.Granted {
+			granted = append(granted, r.peer)
+		}
+	}
+
+	// Our view of the lease ends before any voter's promise does
+	drift := time.Duration(float64(leaseDuration) * DefaultClockDrift)
+	validity := leaseDuration - time.Since(start) - drift
+	won := len(granted) >= b.majority() && validity > 0
+
+	b.mu.Lock()
+	b.seenTerm = max(b.seenTerm, seen)
+	held := b.holder == identity && b.holderTerm == term
+	if won {
+		if b.holder != identity {
+			b.address = ""
+		}
+		b.holder = identity
+		b.holderTerm = term
+		b.holderLease = leaseDuration
+		b.leaseUntil = start.Add(leaseDuration - drift)
+	} else if b.holder == identity {
+		b.holder = ""
+		b.address = ""
+		b.leaseUntil = time.Time{}
+	}
+	b.mu.Unlock()
+
+	if !won {
+		// Free the votes we did collect so another candidate can win
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), leaseDuration/2)
+		defer cancel()
+		b.releaseFrom(releaseCtx, granted, ReleaseRequest{Candidate: identity, Term: term, Abandoned: !held})
+	}
+
+	return won, nil
+}
+
+// releaseFrom sends req to the given peers in parallel.
+func (b *Backend) releaseFrom(ctx context.Context, peers []string, req ReleaseRequest) {
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if peer == b.self {
+				b.HandleRelease(req)
+				return
+			}
+			_ = b.transport.Release(ctx, peer, req)
+		}()
+	}
+	wg.Wait()
+}
+
+// requestVote asks a peer for its vote, answering locally for ourselves.
+func (b *Backend) requestVote(ctx context.Context, peer string, req VoteRequest) (VoteResponse, error) {
+	if peer == b.self {
+		return b.HandleVote(req), nil
+	}
+	return b.transport.RequestVote(ctx, peer, req)
+}
+
+// majority returns the number of votes needed to hold the lease.
+func (b *Backend) majority() int {
+	return len(b.peers)/2 + 1
+}
+
+// localHolder returns the candidate our voter has promised to, if the promise is live.
+func (b *Backend) localHolder() string {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	if time.Now().Before(b.promise.expires) {
+		return b.promise.candidate
+	}
+	return ""
+}
+
+// HandleVote applies a vote request to this peer's voter.
+// A vote is granted unless the term is older than one already granted, or
+// the vote is promised to a different candidate whose promise has not expired.
+func (b *Backend) HandleVote(req VoteRequest) VoteResponse {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	now := time.Now()
+	live := now.Before(b.promise.expires)
+
+	if req.Term < b.term {
+		return VoteResponse{Term: b.term, Holder: b.promise.candidate}
+	}
+	if live && b.promise.candidate != req.Candidate {
+		return VoteResponse{Term: b.term, Holder: b.promise.candidate}
+	}
+
+	granted := now
+	if b.promise.candidate == req.Candidate && b.promise.term == req.Term {
+		// A renewal continues the tenure
+		granted = b.promise.granted
+	} else {
+		b.endPromise(now, req.Candidate)
+	}
+
+	b.term = req.Term
+	b.promise = promise{
+		candidate: req.Candidate,
+		address:   req.Address,
+		term:      req.Term,
+		granted:   granted,
+		expires:   now.Add(req.LeaseDuration),
+	}
+
+	return VoteResponse{Granted: true, Term: b.term, Holder: req.Candidate}
+}
+
+// HandleRelease drops this peer's promise if it is held by the candidate for that term.
+func (b *Backend) HandleRelease(req ReleaseRequest) {
+	b.voteMu.Lock()
+	defer b.voteMu.Unlock()
+
+	if b.promise.candidate == req.Candidate && b.promise.term == req.Term {
+		if !req.Abandoned {
+			b.recordTenure(consensus.EndReleased, time.Now(), "")
+		}
+		b.promise = promise{}
+	}
+}
+
+// endPromise records the promised candidate's tenure as expired because the
+// promise is being replaced by one to successor. Caller holds b.voteMu.
+func (b *Backend) endPromise(now time.Time, successor string) {
+	if b.promise.candidate == "" {
+		return
+	}
+
+	end := now
+	if b.promise.expires.Before(now) {
+		end = b.promise.expires
+	}
+	b.recordTenure(consensus.EndExpired, end, successor)
+}
+
+// recordTenure appends the promised candidate's tenure to the history.
+// Caller holds b.voteMu.
+func (b *Backend) recordTenure(reason consensus.EndReason, end time.Time, successor string) {
+	b.history = b.history.Append(b.promise.tenure(reason, end, successor), b.historyLimit)
+}
+
+// tenure describes the promised candidate's tenure as ending at end.
+func (p promise) tenure(reason consensus.EndReason, end time.Time, successor string) consensus.Tenure {
+	return consensus.Tenure{
+		Identity:    p.candidate,
+		Term:        p.term,
+		AcquireTime: p.granted,
+		EndTime:     end,
+		Reason:      reason,
+		Successor:   successor,
+	}
+}
@@ -0,0 +1,300 @@
+package quorum
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+)
+
+// network tracks injected partitions between peers.
+type network struct {
+	mu      sync.Mutex
+	blocked map[[2]string]bool
+}
+
+func (n *network) partition(a, b []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, x := range a {
+		for _, y := range b {
+			n.blocked[[2]string{x, y}] = true
+			n.blocked[[2]string{y, x}] = true
+		}
+	}
+}
+
+func (n *network) heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.blocked = map[[2]string]bool{}
+}
+
+func (n *network) isBlocked(from, to string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.blocked[[2]string{from, to}]
+}
+
+// partitionTransport drops requests across injected partitions.
+type partitionTransport struct {
+	from string
+	net  *network
+	next Transport
+}
+
+var errPartitioned = errors.New("partitioned")
+
+func (t *partitionTransport) RequestVote(ctx context.Context, peer string, req VoteRequest) (VoteResponse, error) {
+	if t.net.isBlocked(t.from, peer) {
+		return VoteResponse{}, errPartitioned
+	}
+	return t.next.RequestVote(ctx, peer, req)
+}
+
+func (t *partitionTransport) Release(ctx context.Context, peer string, req ReleaseRequest) error {
+	if t.net.isBlocked(t.from, peer) {
+		return errPartitioned
+	}
+	return t.next.Release(ctx, peer, req)
+}
+
+// newCluster starts n peers on loopback HTTP servers.
+func newCluster(t *testing.T, n int) ([]*Backend, []string, *network) {
+	t.Helper()
+
+	net := &network{blocked: map[[2]string]bool{}}
+	handlers := make([]http.Handler, n)
+	addrs := make([]string, n)
+	for i := range n {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[i].ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		addrs[i] = srv.URL
+	}
+
+	nodes := make([]*Backend, n)
+	for i := range n {
+		transport := &partitionTransport{from: addrs[i], net: net, next: NewHTTPTransport(nil)}
+		nodes[i] = NewBackend(addrs[i], addrs, WithTransport(transport), WithElectionJitter(100*time.Millisecond))
+		handlers[i] = nodes[i].Handler()
+	}
+
+	return nodes, addrs, net
+}
+
+func TestAtMostOneLeaderAmongManyPeers(t *testing.T) {
+	nodes, _, _ := newCluster(t, 25)
+	ctx := context.Background()
+	lease := time.Second
+
+	leaders := 0
+	for round := 0; round < 10 && leaders == 0; round++ {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for i, node := range nodes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := node.TryAcquire(ctx, fmt.Sprintf("node-%d", i), lease)
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					mu.Lock()
+					leaders++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if leaders > 1 {
+			t.Fatalf("round %d: %d leaders elected", round, leaders)
+		}
+	}
+
+	if leaders != 1 {
+		t.Fatal("no leader elected after 10 rounds")
+	}
+}
+
+func TestLeaderRenewsAndBlocksOthers(t *testing.T) {
+	nodes, _, _ := newCluster(t, 5)
+	ctx := context.Background()
+	lease := 500 * time.Millisecond
+
+	if ok, err := nodes[0].TryAcquire(ctx, "a", lease); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := nodes[1].TryAcquire(ctx, "b", lease); ok {
+		t.Fatal("second candidate acquired a held lease")
+	}
+
+	// Renewing across several lease durations keeps others out
+	for range 4 {
+		time.Sleep(lease / 3)
+		if err := nodes[0].Renew(ctx, "a", lease); err != nil {
+			t.Fatalf("renew: %v", err)
+		}
+	}
+	if ok, _ := nodes[2].TryAcquire(ctx, "c", lease); ok {
+		t.Fatal("candidate acquired a renewed lease")
+	}
+	if nodes[0].Term() == 0 {
+		t.Fatal("leader has no term")
+	}
+}
+
+func TestReleaseAllowsImmediateTakeover(t *testing.T) {
+	nodes, _, _ := newCluster(t, 5)
+	ctx := context.Background()
+	lease := 10 * time.Second
+
+	if ok, err := nodes[0].TryAcquire(ctx, "a", lease); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	firstTerm := nodes[0].Term()
+
+	if err := nodes[0].Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := nodes[3].TryAcquire(ctx, "d", lease); err != nil || !ok {
+		t.Fatalf("takeover after release: ok=%v err=%v", ok, err)
+	}
+	if nodes[3].Term() <= firstTerm {
+		t.Fatalf("term did not advance: %d -> %d", firstTerm, nodes[3].Term())
+	}
+}
+
+func TestAddressReachesEveryVoter(t *testing.T) {
+	nodes, _, _ := newCluster(t, 5)
+	ctx := context.Background()
+	lease := 10 * time.Second
+
+	if ok, err := nodes[0].TryAcquire(ctx, "a", lease); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := nodes[1].SetAddress(ctx, "b", "http://b:8080"); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("SetAddress by non-holder: got %v, want ErrNotHolder", err)
+	}
+	if err := nodes[0].SetAddress(ctx, "a", "http://a:8080"); err != nil {
+		t.Fatalf("SetAddress: %v", err)
+	}
+
+	for i, node := range nodes {
+		leader, err := node.GetLeader(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leader == nil || leader.Identity != "a" || leader.Address != "http://a:8080" {
+			t.Fatalf("node %d leader = %+v, want a at http://a:8080", i, leader)
+		}
+	}
+
+	// The next leader starts without the previous one's address
+	if err := nodes[0].Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := nodes[0].TryAcquire(ctx, "c", lease); err != nil || !ok {
+		t.Fatalf("takeover: ok=%v err=%v", ok, err)
+	}
+	leader, err := nodes[2].GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Identity != "c" || leader.Address != "" {
+		t.Fatalf("leader = %+v, want c with no address", leader)
+	}
+}
+
+func TestPartitionedLeaderStepsDown(t *testing.T) {
+	nodes, addrs, net := newCluster(t, 5)
+	ctx := context.Background()
+	lease := 300 * time.Millisecond
+
+	if ok, err := nodes[0].TryAcquire(ctx, "a", lease); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+
+	// Leader and one follower end up in the minority
+	net.partition(addrs[:2], addrs[2:])
+
+	if err := nodes[0].Renew(ctx, "a", lease); err == nil {
+		t.Fatal("minority leader renewed its lease")
+	}
+	if ok, _ := nodes[1].TryAcquire(ctx, "b", lease); ok {
+		t.Fatal("minority candidate acquired the lease")
+	}
+
+	// The majority elects a new leader once the old promises expire
+	deadline := time.Now().Add(5 * lease)
+	for {
+		ok, err := nodes[2].TryAcquire(ctx, "c", lease)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("majority did not elect a new leader")
+		}
+		time.Sleep(lease / 5)
+	}
+
+	// After healing, the old leader cannot take the lease back
+	net.heal()
+	if ok, _ := nodes[0].TryAcquire(ctx, "a", lease); ok {
+		t.Fatal("old leader reacquired a held lease")
+	}
+}
+
+func TestBackendConformance(t *testing.T) {
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		nodes, _, _ := newCluster(t, 3)
+		return nodes[0]
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		nodes, _, _ := newCluster(t, 3)
+		return nodes[0]
+	})
+}
+
+func TestHistorySkipsAbandonedCampaigns(t *testing.T) {
+	nodes, addrs, net := newCluster(t, 3)
+	ctx := context.Background()
+
+	// Cut off from both other peers, a candidate wins only its own vote and gives it back
+	net.partition(addrs[:1], addrs[1:])
+	if ok, _ := nodes[0].TryAcquire(ctx, "a", time.Minute); ok {
+		t.Fatal("isolated candidate acquired the lease")
+	}
+	net.heal()
+
+	if ok, err := nodes[0].TryAcquire(ctx, "b", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := nodes[0].Release(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := nodes[0].History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Identity != "b" || history[0].Reason != consensus.EndReleased {
+		t.Fatalf("history = %+v, want only b released", history)
+	}
+}
@@ -0,0 +1,287 @@
+// Package remote implements consensus.Backend against a consensusd lock server.
+package remote
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	consensusv1 "github.com/fraser/consensus/gen/consensus/v1"
+	"github.com/fraser/consensus/gen/consensus/v1/consensusv1connect"
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// DefaultSessionTTL is how long the server keeps this client's leases after it disconnects.
+const DefaultSessionTTL = 10 * time.Second
+
+// reconnectInterval is how long the keep-alive loop waits before reconnecting.
+const reconnectInterval = time.Second
+
+// Option configures a Backend.
+type Option func(*config)
+
+type config struct {
+	httpClient connect.HTTPClient
+	token      string
+	sessionTTL time.Duration
+	clientOpts []connect.ClientOption
+}
+
+// WithHTTPClient sets the HTTP client used to reach the server (default: http.DefaultClient).
+// Use an HTTP/2-capable client together with WithGRPC.
+func WithHTTPClient(client connect.HTTPClient) Option {
+	return func(c *config) {
+		c.httpClient = client
+	}
+}
+
+// WithToken sets the bearer token presented to the server.
+func WithToken(token string) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// WithSessionTTL sets how long the server keeps this client's leases after it
+// disconnects (default: DefaultSessionTTL). The server may cap it.
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.sessionTTL = ttl
+	}
+}
+
+// WithGRPC uses the gRPC protocol instead of Connect.
+func WithGRPC() Option {
+	return func(c *config) {
+		c.clientOpts = append(c.clientOpts, connect.WithGRPC())
+	}
+}
+
+// Backend implements consensus.Backend by calling a lock server.
+// Leases acquired through a Backend belong to its session: if the process
+// dies, the server releases them once the session TTL passes.
+type Backend struct {
+	client     consensusv1connect.LockServiceClient
+	sessionID  string
+	sessionTTL time.Duration
+
+	startOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewBackend creates a client for the lock server at baseURL (e.g. "http://consensusd:8080").
+func NewBackend(baseURL string, opts ...Option) *Backend {
+	cfg := &config{
+		httpClient: http.DefaultClient,
+		sessionTTL: DefaultSessionTTL,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	clientOpts := cfg.clientOpts
+	if cfg.token != "" {
+		clientOpts = append(clientOpts, connect.WithInterceptors(&tokenInterceptor{token: cfg.token}))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Backend{
+		client:     consensusv1connect.NewLockServiceClient(cfg.httpClient, baseURL, clientOpts...),
+		sessionID:  newSessionID(),
+		sessionTTL: cfg.sessionTTL,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// TryAcquire attempts to acquire or renew leadership.
+func (b *Backend) TryAcquire(ctx context.Context, identity string, leaseDuration time.Duration) (bool, error) {
+	b.startOnce.Do(func() { go b.keepAlive() })
+
+	resp, err := b.client.TryAcquire(ctx, connect.NewRequest(&consensusv1.TryAcquireRequest{
+		Identity:      identity,
+		LeaseDuration: durationpb.New(leaseDuration),
+		SessionId:     b.sessionID,
+	}))
+	if err != nil {
+		return false, fromConnectError(err)
+	}
+
+	return resp.Msg.Acquired, nil
+}
+
+// Renew extends the current leader's lease.
+func (b *Backend) Renew(ctx context.Context, identity string, leaseDuration time.Duration) error {
+	_, err := b.client.Renew(ctx, connect.NewRequest(&consensusv1.RenewRequest{
+		Identity:      identity,
+		LeaseDuration: durationpb.New(leaseDuration),
+		SessionId:     b.sessionID,
+	}))
+	return fromConnectError(err)
+}
+
+// Release explicitly gives up leadership.
+func (b *Backend) Release(ctx context.Context, identity string) error {
+	_, err := b.client.Release(ctx, connect.NewRequest(&consensusv1.ReleaseRequest{
+		Identity:  identity,
+		SessionId: b.sessionID,
+	}))
+	return fromConnectError(err)
+}
+
+// SetAddress records the address at which identity serves requests.
+func (b *Backend) SetAddress(ctx context.Context, identity, address string) error {
+	_, err := b.client.SetAddress(ctx, connect.NewRequest(&consensusv1.SetAddressRequest{
+		Identity: identity,
+		Address:  address,
+	}))
+	return fromConnectError(err)
+}
+
+// GetLeader returns the current lease holder, or nil if there is no live leader.
+func (b *Backend) GetLeader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	resp, err := b.client.GetLeader(ctx, connect.NewRequest(&consensusv1.GetLeaderRequest{}))
+	if err != nil {
+		return nil, fromConnectError(err)
+	}
+	return toRecord(resp.Msg.Leader), nil
+}
+
+// History returns the past tenures recorded by the server's backend, oldest first.
+func (b *Backend) History(ctx context.Context) ([]consensus.Tenure, error) {
+	resp, err := b.client.History(ctx, connect.NewRequest(&consensusv1.HistoryRequest{}))
+	if err != nil {
+		return nil, fromConnectError(err)
+	}
+
+	history := make([]consensus.Tenure, len(resp.Msg.Tenures))
+	for i, t := range resp.Msg.Tenures {
+		history[i] = consensus.Tenure{
+			Identity:    t.Identity,
+			Term:        t.Term,
+			AcquireTime: t.AcquireTime.AsTime(),
+			EndTime:     t.EndTime.AsTime(),
+			Reason:      consensus.EndReason(t.Reason),
+			Successor:   t.Successor,
+		}
+	}
+	return history, nil
+}
+
+// Watch streams the lease holder whenever it changes, starting with the
+// current one. A nil record means there is no live leader. The channel is
+// closed when ctx is cancelled or the stream fails.
+func (b *Backend) Watch(ctx context.Context) (<-chan *consensus.LeaderRecord, error) {
+	stream, err := b.client.Watch(ctx, connect.NewRequest(&consensusv1.WatchRequest{}))
+	if err != nil {
+		return nil, fromConnectError(err)
+	}
+
+	ch := make(chan *consensus.LeaderRecord)
+	go func() {
+		defer close(ch)
+		defer stream.Close()
+		for stream.Receive() {
+			select {
+			case ch <- toRecord(stream.Msg().Leader):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Close ends the session. Leases still held are released by the server
+// once the session TTL passes; call Release first to hand over immediately.
+func (b *Backend) Close() error {
+	b.cancel()
+	return nil
+}
+
+// keepAlive holds the session stream open, reconnecting until Close is called.
+func (b *Backend) keepAlive() {
+	for {
+		stream, err := b.client.KeepAlive(b.ctx, connect.NewRequest(&consensusv1.KeepAliveRequest{
+			SessionId: b.sessionID,
+			Ttl:       durationpb.New(b.sessionTTL),
+		}))
+		if err == nil {
+			for stream.Receive() {
+			}
+			stream.Close()
+		}
+
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+// toRecord converts a wire leader to a LeaderRecord.
+func toRecord(leader *consensusv1.Leader) *consensus.LeaderRecord {
+	if leader == nil {
+		return nil
+	}
+	return &consensus.LeaderRecord{
+		Identity:      leader.Identity,
+		Address:       leader.Address,
+		AcquireTime:   leader.AcquireTime.AsTime(),
+		RenewTime:     leader.RenewTime.AsTime(),
+		LeaseDuration: leader.LeaseDuration.AsDuration(),
+		Transitions:   leader.Transitions,
+	}
+}
+
+// fromConnectError maps Connect codes back to consensus errors.
+func fromConnectError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if connect.CodeOf(err) == connect.CodeFailedPrecondition {
+		return errors.Join(consensus.ErrNotHolder, err)
+	}
+	return err
+}
+
+// newSessionID returns a random session identifier.
+func newSessionID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// tokenInterceptor attaches a bearer token to every request.
+type tokenInterceptor struct {
+	token string
+}
+
+func (t *tokenInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		req.Header().Set("Authorization", "Bearer "+t.token)
+		return next(ctx, req)
+	}
+}
+
+func (t *tokenInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		conn.RequestHeader().Set("Authorization", "Bearer "+t.token)
+		return conn
+	}
+}
+
+func (t *tokenInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
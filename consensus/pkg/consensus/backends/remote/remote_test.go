@@ -0,0 +1,310 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	consensusv1 "github.com/fraser/consensus/gen/consensus/v1"
+	"github.com/fraser/consensus/gen/consensus/v1/consensusv1connect"
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+	"github.com/fraser/consensus/pkg/consensus/consensustest"
+	"github.com/fraser/consensus/pkg/consensus/lockserver"
+)
+
+func newServer(t *testing.T, opts ...lockserver.Option) (*httptest.Server, *file.Backend) {
+	t.Helper()
+
+	store := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	mux := http.NewServeMux()
+	mux.Handle(lockserver.New(store, opts...).Handler())
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, store
+}
+
+func TestAcquireRenewRelease(t *testing.T) {
+	srv, _ := newServer(t)
+	ctx := context.Background()
+
+	a := NewBackend(srv.URL)
+	defer a.Close()
+	b := NewBackend(srv.URL)
+	defer b.Close()
+
+	if ok, err := a.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if ok, err := b.TryAcquire(ctx, "b", time.Minute); err != nil || ok {
+		t.Fatalf("second acquire: ok=%v err=%v", ok, err)
+	}
+	if err := a.Renew(ctx, "a", time.Minute); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	if err := b.Renew(ctx, "b", time.Minute); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("renew by non-holder: got %v, want ErrNotHolder", err)
+	}
+
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Identity != "a" {
+		t.Fatalf("leader = %+v, want a", leader)
+	}
+
+	if err := a.Release(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.TryAcquire(ctx, "b", time.Minute); err != nil || !ok {
+		t.Fatalf("takeover: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestSetAddress(t *testing.T) {
+	srv, _ := newServer(t)
+	ctx := context.Background()
+
+	a := NewBackend(srv.URL)
+	defer a.Close()
+	b := NewBackend(srv.URL)
+	defer b.Close()
+
+	if ok, err := a.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if err := b.SetAddress(ctx, "b", "http://b:8080"); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("SetAddress by non-holder: got %v, want ErrNotHolder", err)
+	}
+	if err := a.SetAddress(ctx, "a", "http://a:8080"); err != nil {
+		t.Fatalf("SetAddress: %v", err)
+	}
+
+	leader, err := b.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Identity != "a" || leader.Address != "http://a:8080" {
+		t.Fatalf("leader = %+v, want a at http://a:8080", leader)
+	}
+}
+
+func TestGetLeaderReportsTransitions(t *testing.T) {
+	srv, store := newServer(t)
+	ctx := context.Background()
+
+	client := NewBackend(srv.URL)
+	defer client.Close()
+
+	for _, identity := range []string{"a", "b"} {
+		if ok, err := client.TryAcquire(ctx, identity, time.Minute); err != nil || !ok {
+			t.Fatalf("acquire %s: ok=%v err=%v", identity, ok, err)
+		}
+		if err := client.Release(ctx, identity); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, err := client.TryAcquire(ctx, "c", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire c: ok=%v err=%v", ok, err)
+	}
+
+	want, err := store.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Identity != "c" || got.Transitions != want.Transitions || got.Transitions == 0 {
+		t.Fatalf("leader = %+v, want c with transitions %d", got, want.Transitions)
+	}
+}
+
+func TestTokenRequired(t *testing.T) {
+	srv, _ := newServer(t, lockserver.WithTokens("secret"))
+	ctx := context.Background()
+
+	anonymous := NewBackend(srv.URL)
+	defer anonymous.Close()
+	if _, err := anonymous.TryAcquire(ctx, "a", time.Minute); connect.CodeOf(err) != connect.CodeUnauthenticated {
+		t.Fatalf("got %v, want unauthenticated", err)
+	}
+
+	authed := NewBackend(srv.URL, WithToken("secret"))
+	defer authed.Close()
+	if ok, err := authed.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire with token: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestSessionExpiryReleasesLeases(t *testing.T) {
+	srv, store := newServer(t, lockserver.WithMaxSessionTTL(200*time.Millisecond))
+	ctx := context.Background()
+
+	client := NewBackend(srv.URL, WithSessionTTL(200*time.Millisecond))
+	if ok, err := client.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+
+	// Give the keep-alive stream time to connect, then disconnect the client
+	time.Sleep(100 * time.Millisecond)
+	client.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		leader, err := store.GetLeader(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leader == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lease still held by %q after session expiry", leader.Identity)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestSessionWithoutKeepAliveExpires(t *testing.T) {
+	srv, store := newServer(t, lockserver.WithMaxSessionTTL(200*time.Millisecond))
+	ctx := context.Background()
+
+	// A client that acquires but never opens a KeepAlive stream or renews
+	client := consensusv1connect.NewLockServiceClient(srv.Client(), srv.URL)
+	resp, err := client.TryAcquire(ctx, connect.NewRequest(&consensusv1.TryAcquireRequest{
+		Identity:      "a",
+		SessionId:     "silent",
+		LeaseDuration: durationpb.New(300 * time.Millisecond),
+	}))
+	if err != nil || !resp.Msg.Acquired {
+		t.Fatalf("acquire: resp=%v err=%v", resp, err)
+	}
+
+	// The lease lapses on its own; only the session's expiry releases it,
+	// ending the tenure in the history
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		history, err := store.History(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) == 1 && history[0].Identity == "a" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("history = %+v, want a's tenure ended by session expiry", history)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestRenewWithoutKeepAliveOutlivesSessionTTL(t *testing.T) {
+	srv, store := newServer(t, lockserver.WithMaxSessionTTL(100*time.Millisecond))
+	ctx := context.Background()
+
+	// A client that renews well within its lease but less often than the
+	// session TTL, without ever opening a KeepAlive stream
+	client := consensusv1connect.NewLockServiceClient(srv.Client(), srv.URL)
+	resp, err := client.TryAcquire(ctx, connect.NewRequest(&consensusv1.TryAcquireRequest{
+		Identity:      "a",
+		SessionId:     "renewer",
+		LeaseDuration: durationpb.New(time.Second),
+	}))
+	if err != nil || !resp.Msg.Acquired {
+		t.Fatalf("acquire: resp=%v err=%v", resp, err)
+	}
+
+	for range 3 {
+		time.Sleep(300 * time.Millisecond)
+		if _, err := client.Renew(ctx, connect.NewRequest(&consensusv1.RenewRequest{
+			Identity:      "a",
+			SessionId:     "renewer",
+			LeaseDuration: durationpb.New(time.Second),
+		})); err != nil {
+			t.Fatalf("renew: %v", err)
+		}
+	}
+
+	leader, err := store.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader == nil || leader.Identity != "a" {
+		t.Fatalf("leader = %+v, want a", leader)
+	}
+}
+
+func TestKeepAliveToleratesTinyTTL(t *testing.T) {
+	srv, _ := newServer(t, lockserver.WithMaxSessionTTL(0))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := consensusv1connect.NewLockServiceClient(srv.Client(), srv.URL)
+	stream, err := client.KeepAlive(ctx, connect.NewRequest(&consensusv1.KeepAliveRequest{
+		SessionId: "tiny",
+		Ttl:       durationpb.New(time.Nanosecond),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	for range 3 {
+		if !stream.Receive() {
+			t.Fatalf("keep-alive stream ended: %v", stream.Err())
+		}
+	}
+}
+
+func TestWatchReportsChanges(t *testing.T) {
+	srv, _ := newServer(t, lockserver.WithWatchInterval(20*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := NewBackend(srv.URL)
+	defer client.Close()
+
+	updates, err := client.Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := <-updates; first != nil {
+		t.Fatalf("initial leader = %+v, want none", first)
+	}
+
+	if ok, err := client.TryAcquire(ctx, "a", time.Minute); err != nil || !ok {
+		t.Fatalf("acquire: ok=%v err=%v", ok, err)
+	}
+	if next := <-updates; next == nil || next.Identity != "a" {
+		t.Fatalf("leader = %+v, want a", next)
+	}
+}
+
+func TestBackendConformance(t *testing.T) {
+	consensustest.RunBackendSuite(t, func(t *testing.T) consensus.Backend {
+		srv, _ := newServer(t)
+		client := NewBackend(srv.URL)
+		t.Cleanup(func() { client.Close() })
+		return client
+	})
+}
+
+func TestHistoryConformance(t *testing.T) {
+	consensustest.RunHistorySuite(t, func(t *testing.T) consensustest.HistoryBackend {
+		srv, _ := newServer(t)
+		client := NewBackend(srv.URL)
+		t.Cleanup(func() { client.Close() })
+		return client
+	})
+}
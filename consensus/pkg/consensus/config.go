@@ -0,0 +1,88 @@
+package consensus
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidConfig indicates a Config that cannot be used for an election.
+var ErrInvalidConfig = errors.New("invalid consensus configuration")
+
+// ConfigFromEnv creates a Config from environment variables, starting from NewConfig's defaults.
+// Durations accept Go syntax ("15s", "1m30s") or a plain number of seconds.
+// Environment variables:
+//
+//	CONSENSUS_IDENTITY          - identity of this instance (default: POD_NAME, then the hostname)
+//	CONSENSUS_LEASE_DURATION    - lease duration (default: "15s")
+//	CONSENSUS_RENEW_INTERVAL    - leader renewal interval (default: "5s")
+//	CONSENSUS_RETRY_INTERVAL    - follower acquisition interval (default: "2s")
+//	CONSENSUS_ADVERTISE_ADDRESS - address advertised while leading (default: none)
+//
+// The result is validated; errors wrap ErrInvalidConfig.
+func ConfigFromEnv() (Config, error) {
+	identity := os.Getenv("CONSENSUS_IDENTITY")
+	if identity == "" {
+		identity = os.Getenv("POD_NAME")
+	}
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+
+	config := NewConfig(identity)
+	config.AdvertiseAddress = os.Getenv("CONSENSUS_ADVERTISE_ADDRESS")
+
+	for _, v := range []struct {
+		name string
+		dst  *time.Duration
+	}{
+		{"CONSENSUS_LEASE_DURATION", &config.LeaseDuration},
+		{"CONSENSUS_RENEW_INTERVAL", &config.RenewInterval},
+		{"CONSENSUS_RETRY_INTERVAL", &config.RetryInterval},
+	} {
+		raw := os.Getenv(v.name)
+		if raw == "" {
+			continue
+		}
+		d, err := ParseDuration(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, v.name, err)
+		}
+		*v.dst = d
+	}
+
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Validate checks that the configuration can hold a lease: every duration is
+// positive and the leader renews well before its lease expires.
+func (c Config) Validate() error {
+	switch {
+	case c.Identity == "":
+		return fmt.Errorf("%w: identity is required", ErrInvalidConfig)
+	case c.LeaseDuration <= 0:
+		return fmt.Errorf("%w: lease duration must be positive", ErrInvalidConfig)
+	case c.RenewInterval <= 0:
+		return fmt.Errorf("%w: renew interval must be positive", ErrInvalidConfig)
+	case c.RetryInterval <= 0:
+		return fmt.Errorf("%w: retry interval must be positive", ErrInvalidConfig)
+	case c.HealthFailureThreshold < 0:
+		return fmt.Errorf("%w: health failure threshold must not be negative", ErrInvalidConfig)
+	case c.RenewInterval >= c.LeaseDuration:
+		return fmt.Errorf("%w: renew interval %v must be shorter than lease duration %v", ErrInvalidConfig, c.RenewInterval, c.LeaseDuration)
+	}
+	return nil
+}
+
+// ParseDuration parses a Go duration string or a plain number of seconds.
+func ParseDuration(s string) (time.Duration, error) {
+	if secs, err := strconv.Atoi(s); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
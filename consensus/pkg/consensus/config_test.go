@@ -0,0 +1,137 @@
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CONSENSUS_IDENTITY", "worker-1")
+	t.Setenv("CONSENSUS_LEASE_DURATION", "30")
+	t.Setenv("CONSENSUS_RENEW_INTERVAL", "10s")
+	t.Setenv("CONSENSUS_RETRY_INTERVAL", "1m30s")
+	t.Setenv("CONSENSUS_ADVERTISE_ADDRESS", "10.0.0.1:8080")
+
+	config, err := consensus.ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Identity != "worker-1" || config.LeaseDuration != 30*time.Second ||
+		config.RenewInterval != 10*time.Second || config.RetryInterval != 90*time.Second ||
+		config.AdvertiseAddress != "10.0.0.1:8080" {
+		t.Fatalf("config = %+v", config)
+	}
+}
+
+func TestConfigFromEnvIdentityFallback(t *testing.T) {
+	t.Setenv("CONSENSUS_IDENTITY", "")
+	t.Setenv("POD_NAME", "pod-7")
+
+	config, err := consensus.ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Identity != "pod-7" {
+		t.Fatalf("identity = %q, want POD_NAME", config.Identity)
+	}
+}
+
+func TestConfigFromEnvRejectsBadValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"unparseable lease", "CONSENSUS_LEASE_DURATION", "soon"},
+		{"unparseable renew", "CONSENSUS_RENEW_INTERVAL", "5 seconds"},
+		{"unparseable retry", "CONSENSUS_RETRY_INTERVAL", "2x"},
+		{"zero lease", "CONSENSUS_LEASE_DURATION", "0"},
+		{"negative retry", "CONSENSUS_RETRY_INTERVAL", "-1s"},
+		{"renew not shorter than default lease", "CONSENSUS_RENEW_INTERVAL", "15s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONSENSUS_IDENTITY", "worker-1")
+			t.Setenv(tt.key, tt.value)
+
+			if _, err := consensus.ConfigFromEnv(); !errors.Is(err, consensus.ErrInvalidConfig) {
+				t.Fatalf("%s=%q: got %v, want ErrInvalidConfig", tt.key, tt.value, err)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := consensus.NewConfig("a")
+
+	tests := []struct {
+		name   string
+		modify func(*consensus.Config)
+		ok     bool
+	}{
+		{"defaults", func(c *consensus.Config) {}, true},
+		{"missing identity", func(c *consensus.Config) { c.Identity = "" }, false},
+		{"zero lease", func(c *consensus.Config) { c.LeaseDuration = 0 }, false},
+		{"negative renew", func(c *consensus.Config) { c.RenewInterval = -time.Second }, false},
+		{"zero retry", func(c *consensus.Config) { c.RetryInterval = 0 }, false},
+		{"negative health threshold", func(c *consensus.Config) { c.HealthFailureThreshold = -1 }, false},
+		{"renew equals lease", func(c *consensus.Config) { c.RenewInterval = c.LeaseDuration }, false},
+		{"renew longer than lease", func(c *consensus.Config) { c.RenewInterval = 2 * c.LeaseDuration }, false},
+		{"renew just under lease", func(c *consensus.Config) { c.RenewInterval = c.LeaseDuration - time.Millisecond }, true},
+		{"retry longer than lease", func(c *consensus.Config) { c.RetryInterval = time.Minute }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid
+			tt.modify(&config)
+
+			err := config.Validate()
+			if tt.ok && err != nil {
+				t.Fatalf("Validate() = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, consensus.ErrInvalidConfig) {
+				t.Fatalf("Validate() = %v, want ErrInvalidConfig", err)
+			}
+		})
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	manager := consensus.NewManager(backend, fastConfig("a"))
+	lease := manager.Start(context.Background())
+	defer manager.Stop()
+	eventually(t, "leadership", lease.IsLeader)
+
+	renamed := fastConfig("b")
+	if err := manager.UpdateConfig(renamed); !errors.Is(err, consensus.ErrInvalidConfig) {
+		t.Fatalf("identity change: got %v, want ErrInvalidConfig", err)
+	}
+
+	invalid := fastConfig("a")
+	invalid.RenewInterval = invalid.LeaseDuration
+	if err := manager.UpdateConfig(invalid); !errors.Is(err, consensus.ErrInvalidConfig) {
+		t.Fatalf("invalid timing: got %v, want ErrInvalidConfig", err)
+	}
+
+	longer := fastConfig("a")
+	longer.LeaseDuration = time.Minute
+	if err := manager.UpdateConfig(longer); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "renewal with the new duration", func() bool {
+		leader, err := backend.GetLeader(context.Background())
+		return err == nil && leader != nil && leader.LeaseDuration == time.Minute
+	})
+	if !lease.IsLeader() {
+		t.Fatal("leadership lost while updating timing")
+	}
+}
@@ -2,17 +2,51 @@ package consensus
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config defines the configuration for leader election.
 type Config struct {
-	Identity      string        // Unique identifier for this instance (e.g., POD_NAME)
-	LeaseDuration time.Duration // How long a lease is valid before expiring
-	RenewInterval time.Duration // How often the leader renews its lease
-	RetryInterval time.Duration // How often non-leaders retry acquiring leadership
+	Identity         string        // Unique identifier for this instance (e.g., POD_NAME)
+	LeaseDuration    time.Duration // How long a lease is valid before expiring
+	RenewInterval    time.Duration // How often the leader renews its lease
+	RetryInterval    time.Duration // How often non-leaders retry acquiring leadership
+	AdvertiseAddress string        // Address followers use to reach this instance when it leads (optional)
+	Hooks            []Hook        // Observers of leadership transitions (optional)
+
+	// HealthCheck reports whether this instance can do the leader's work (optional).
+	// While it fails the instance does not campaign, and a leader steps down
+	// after HealthFailureThreshold consecutive failures.
+	HealthCheck            func(ctx context.Context) error
+	HealthFailureThreshold int // Consecutive failures before a leader steps down (default: DefaultHealthFailureThreshold)
+
+	// FairQueue makes followers wait their turn in the backend's candidate
+	// queue, if it has one; see CandidateQueue.
+	FairQueue bool
+
+	// Priority and Version are published in this instance's membership record
+	// (see Manager.Members) for operators; they do not affect the election.
+	Priority int
+	Version  string
+
+	// DisableMembership stops this instance from heartbeating a membership
+	// record, e.g. when shards.Manager runs one election per shard and
+	// records membership once for all of them.
+	DisableMembership bool
+
+	// Logger receives structured logs of leadership transitions and backend
+	// failures, with identity, holder, backend, term and error fields (default: discard).
+	Logger *slog.Logger
+	// TracerProvider creates spans around TryAcquire, Renew and Release
+	// (default: the global OpenTelemetry provider).
+	TracerProvider trace.TracerProvider
 }
 
 // NewConfig creates a Config with sensible defaults.
@@ -20,12 +54,15 @@ type Config struct {
 func NewConfig(identity string) Config {
 	return Config{
 		Identity:      identity,
-		LeaseDuration: 5 * time.Second,
-		RenewInterval: 3 * time.Second,
+		LeaseDuration: 15 * time.Second,
+		RenewInterval: 5 * time.Second,
 		RetryInterval: 2 * time.Second,
 	}
 }
 
+// operationTimeout bounds backend calls made outside the election loop's context.
+const operationTimeout = 5 * time.Second
+
 // Manager manages leader election using a pluggable backend.
 type Manager struct {
 	backend Backend
@@ -34,15 +71,30 @@ type Manager struct {
 	mu            sync.Mutex
 	lease         *Lease
 	cancel        context.CancelFunc
+	done          chan struct{}
+	stepDownCh    chan struct{}
 	stopOnce      sync.Once
+	holder        string // Identity plus session ID, as stored in the backend
 	renewFailures int
+	queued        bool
+	holdOffUntil  time.Time
+	tickInterval  time.Duration
+	lastHeartbeat time.Time
+	log           *slog.Logger
+	tracer        trace.Tracer
 }
 
 // NewManager creates a new leader election manager.
+// Each manager campaigns under a fresh session ID appended to config.Identity.
 func NewManager(backend Backend, config Config) *Manager {
+	holder := HolderID(config.Identity, newSessionID())
 	return &Manager{
-		backend: backend,
-		config:  config,
+		backend:    backend,
+		config:     config,
+		holder:     holder,
+		stepDownCh: make(chan struct{}, 1),
+		log:        newLogger(config, backend, holder),
+		tracer:     newTracer(config),
 	}
 }
 
@@ -53,6 +105,7 @@ func (m *Manager) Start(ctx context.Context) *Lease {
 	m.cancel = cancel
 
 	lease := &Lease{
+		manager:  m,
 		isLeader: atomic.Bool{},
 		leaderCh: make(chan struct{}),
 	}
@@ -60,6 +113,7 @@ func (m *Manager) Start(ctx context.Context) *Lease {
 
 	m.mu.Lock()
 	m.lease = lease
+	m.done = make(chan struct{})
 	m.mu.Unlock()
 
 	go m.run(ctx)
@@ -68,6 +122,7 @@ func (m *Manager) Start(ctx context.Context) *Lease {
 }
 
 // Stop gracefully stops leader election and releases leadership if held.
+// It returns once the election loop has exited and the release has been attempted.
 func (m *Manager) Stop() error {
 	var err error
 	m.stopOnce.Do(func() {
@@ -75,88 +130,292 @@ func (m *Manager) Stop() error {
 			m.cancel()
 		}
 	})
+
+	m.mu.Lock()
+	done := m.done
+	m.mu.Unlock()
+	if done != nil {
+		<-done
+	}
 	return err
 }
 
+// StepDown releases leadership if held and stays out of the election for one
+// LeaseDuration, giving other candidates the chance to take over.
+// It returns immediately; the release happens on the election loop.
+func (m *Manager) StepDown() {
+	select {
+	case m.stepDownCh <- struct{}{}:
+	default:
+	}
+}
+
+// UpdateConfig changes the lease duration and intervals of a running manager.
+// They take effect on the next tick without giving up leadership: the next
+// renewal extends the lease by the new duration. Backends built with a fixed
+// TTL, such as file.WithTTL and lease.WithTTL (which their NewFromEnv
+// constructors apply), ignore the requested duration, so for them only the
+// intervals change. The identity must match the manager's; other fields of
+// config are ignored.
+func (m *Manager) UpdateConfig(config Config) error {
+	if config.Identity != m.config.Identity {
+		return fmt.Errorf("%w: identity cannot change from %q", ErrInvalidConfig, m.config.Identity)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.config.LeaseDuration = config.LeaseDuration
+	m.config.RenewInterval = config.RenewInterval
+	m.config.RetryInterval = config.RetryInterval
+	m.mu.Unlock()
+
+	m.log.Info("timing updated",
+		"lease_duration", config.LeaseDuration,
+		"renew_interval", config.RenewInterval,
+		"retry_interval", config.RetryInterval)
+	return nil
+}
+
+// timing returns the current lease duration and intervals.
+func (m *Manager) timing() (leaseDuration, renewInterval, retryInterval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.config.LeaseDuration, m.config.RenewInterval, m.config.RetryInterval
+}
+
+// Identity returns the identity this manager campaigns with.
+func (m *Manager) Identity() string {
+	return m.config.Identity
+}
+
+// HolderID returns the identity plus this process's session ID, as stored in
+// the backend. It differs between two processes with the same identity, so a
+// restarted process cannot inherit its predecessor's lease.
+func (m *Manager) HolderID() string {
+	return m.holder
+}
+
+// IsLeader returns true if this manager has been started and currently holds leadership.
+func (m *Manager) IsLeader() bool {
+	m.mu.Lock()
+	lease := m.lease
+	m.mu.Unlock()
+
+	return lease != nil && lease.IsLeader()
+}
+
+// Leader returns the current lease holder as recorded by the backend,
+// or nil if there is no live leader.
+func (m *Manager) Leader(ctx context.Context) (*LeaderRecord, error) {
+	lr, ok := m.backend.(LeaderReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: backend does not report the leader", errors.ErrUnsupported)
+	}
+
+	record, err := lr.GetLeader(ctx)
+	if err != nil || record == nil {
+		return record, err
+	}
+	record.Identity, record.Session = SplitHolder(record.Identity)
+	return record, nil
+}
+
 // run is the main election loop that runs in a goroutine.
 func (m *Manager) run(ctx context.Context) {
-	ticker := time.NewTicker(m.config.RetryInterval)
+	defer close(m.done)
+
+	_, _, retryInterval := m.timing()
+	m.tickInterval = retryInterval
+	ticker := time.NewTicker(retryInterval)
 	defer ticker.Stop()
 
+	// Backends that push changes let followers react to a release immediately
+	var changes <-chan *LeaderRecord
+	if w, ok := m.backend.(Watcher); ok {
+		if ch, err := w.Watch(ctx); err == nil {
+			changes = ch
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			// Release leadership if we hold it
-			if m.lease.IsLeader() {
-				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				_ = m.backend.Release(releaseCtx, m.config.Identity)
-				cancel()
-			}
+			m.release(nil)
+			m.leaveQueue()
+			m.leaveMembership()
 			return
 
+		case <-m.stepDownCh:
+			m.release(nil)
+			leaseDuration, _, _ := m.timing()
+			m.holdOffUntil = time.Now().Add(leaseDuration)
+			m.adjustTicker(ticker)
+
 		case <-ticker.C:
 			m.tick(ctx)
+			m.adjustTicker(ticker)
+
+		case record, ok := <-changes:
+			if !ok {
+				// Fall back to polling
+				changes = nil
+				continue
+			}
+			if record == nil && !m.lease.IsLeader() {
+				m.tick(ctx)
+				m.adjustTicker(ticker)
+			}
 		}
 	}
 }
 
+// release gives up leadership with the backend if held and demotes.
+// cause is reported to hooks along with any release error.
+func (m *Manager) release(cause error) {
+	if !m.lease.IsLeader() {
+		return
+	}
+
+	releaseCtx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	err := m.releaseLease(releaseCtx)
+	cancel()
+
+	m.loseLeadership(EventReleased, errors.Join(cause, err))
+}
+
 // tick handles one iteration of the election loop.
 func (m *Manager) tick(ctx context.Context) {
-	if m.lease.IsLeader() {
+	leaseDuration, _, retryInterval := m.timing()
+	healthFailures, healthErr := m.checkHealth(ctx)
+	m.heartbeat(ctx)
+
+	if m.lease.IsLeader() && healthFailures >= m.healthFailureThreshold() {
+		// A sick leader hands over rather than holding the lease while doing nothing
+		m.release(healthErr)
+	} else if m.lease.IsLeader() {
 		// We're the leader - try to renew
-		err := m.backend.Renew(ctx, m.config.Identity, m.config.LeaseDuration)
-		if err != nil {
+		err := m.renew(ctx, leaseDuration)
+		if errors.Is(err, ErrNotHolder) {
+			// Someone else holds the lease (e.g. after a transfer) - demote at once
+			m.loseLeadership(EventLost, err)
+		} else if err != nil {
 			m.renewFailures++
+			m.emit(EventRenewFailed, err)
 			// Allow transient failures, but demote after consecutive failures
 			if m.renewFailures >= 2 {
-				m.loseLeadership()
+				m.loseLeadership(EventLost, err)
 			}
 		} else {
 			m.renewFailures = 0
 		}
-	} else {
-		// We're not the leader - try to acquire
-		acquired, err := m.backend.TryAcquire(ctx, m.config.Identity, m.config.LeaseDuration)
-		if err == nil && acquired {
-			m.gainLeadership()
+	} else if healthErr != nil {
+		// Sick followers don't hold up the queue
+		m.leaveQueue()
+	} else if time.Now().After(m.holdOffUntil) {
+		// We're not the leader and healthy - try to acquire if it's our turn
+		var acquired bool
+		var err error
+		if m.mayCampaign(ctx, retryInterval) {
+			acquired, err = m.tryAcquire(ctx, leaseDuration)
+		}
+		if err != nil {
+			m.log.Warn("acquire attempt failed", "error", err)
+		}
+		if err == nil && (acquired || m.claimTransfer(ctx, leaseDuration)) {
+			m.gainLeadership(ctx)
 		}
 	}
-
-	// Adjust ticker interval based on leadership state
-	m.adjustTicker()
 }
 
 // gainLeadership transitions to leader state.
-func (m *Manager) gainLeadership() {
+// The previous leader's state is loaded and our address advertised before
+// waiters are signalled; if either fails, leadership is not taken this tick
+// and acquisition is retried.
+func (m *Manager) gainLeadership(ctx context.Context) {
+	if m.lease.IsLeader() {
+		return
+	}
+
+	if sb, ok := m.backend.(StateBackend); ok {
+		state, err := sb.GetState(ctx)
+		if err != nil {
+			return
+		}
+		m.lease.stateMu.Lock()
+		m.lease.state = state
+		m.lease.stateMu.Unlock()
+	}
+
+	if m.config.AdvertiseAddress != "" {
+		if aa, ok := m.backend.(AddressAdvertiser); ok {
+			if err := aa.SetAddress(ctx, m.holder, m.config.AdvertiseAddress); err != nil {
+				return
+			}
+		}
+	}
+
+	// The term is informational, so a backend that cannot report it doesn't block leadership
+	if lr, ok := m.backend.(LeaderReader); ok {
+		if record, err := lr.GetLeader(ctx); err == nil && record != nil && record.Identity == m.holder {
+			m.lease.term.Store(record.Transitions)
+		}
+	}
+
 	if !m.lease.isLeader.Swap(true) {
 		// We just became leader
 		close(m.lease.leaderCh)
 		m.renewFailures = 0
+		m.leaveQueue()
+		m.emit(EventAcquired, nil)
 	}
 }
 
-// loseLeadership transitions to non-leader state.
-func (m *Manager) loseLeadership() {
+// loseLeadership transitions to non-leader state and reports why to hooks.
+func (m *Manager) loseLeadership(eventType EventType, cause error) {
 	if m.lease.isLeader.Swap(false) {
 		// We just lost leadership - recreate the channel
 		m.lease.mu.Lock()
 		m.lease.leaderCh = make(chan struct{})
 		m.lease.mu.Unlock()
 		m.renewFailures = 0
+		m.emit(eventType, cause)
 	}
 }
 
-// adjustTicker adjusts the ticker interval based on leadership state.
-func (m *Manager) adjustTicker() {
-	// This is a simplified version - in practice, we'd need to recreate the ticker
-	// with the appropriate interval. For POC, we'll use RetryInterval as base.
+// adjustTicker switches the ticker between the renew and retry intervals
+// as leadership changes, and picks up intervals changed by UpdateConfig.
+func (m *Manager) adjustTicker(ticker *time.Ticker) {
+	_, renewInterval, retryInterval := m.timing()
+
+	interval := retryInterval
+	if m.lease.IsLeader() {
+		interval = renewInterval
+	}
+
+	if interval != m.tickInterval {
+		ticker.Reset(interval)
+		m.tickInterval = interval
+	}
 }
 
 // Lease represents a lease on leadership that can be queried.
 type Lease struct {
+	manager  *Manager
 	isLeader atomic.Bool
 	mu       sync.Mutex
 	leaderCh chan struct{}
+
+	term atomic.Uint64
+
+	stateMu sync.Mutex
+	state   []byte
+
+	healthMu       sync.Mutex
+	healthErr      error
+	healthFailures int
 }
 
 // IsLeader returns true if this instance is currently the leader.
@@ -184,3 +443,44 @@ func (l *Lease) WaitForLeadership(ctx context.Context) error {
 		return ctx.Err()
 	}
 }
+
+// Term returns the backend's transition count when this instance last
+// acquired leadership. It increases with every change of leader, so it can be
+// passed to downstream systems as a fencing token. Zero if the backend does not report it.
+func (l *Lease) Term() uint64 {
+	return l.term.Load()
+}
+
+// State returns the checkpoint payload stored with the lease.
+// It is loaded from the backend when this instance acquires leadership,
+// so a new leader sees the last value written by its predecessor.
+func (l *Lease) State() []byte {
+	l.stateMu.Lock()
+	defer l.stateMu.Unlock()
+	return l.state
+}
+
+// SetState writes a checkpoint payload into the lease record.
+// Only the current holder may write; a deposed leader gets an error wrapping ErrNotLeader or ErrNotHolder.
+func (l *Lease) SetState(state []byte) error {
+	if !l.IsLeader() {
+		return ErrNotLeader
+	}
+
+	sb, ok := l.manager.backend.(StateBackend)
+	if !ok {
+		return fmt.Errorf("%w: backend does not store state", errors.ErrUnsupported)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+
+	if err := sb.SetState(ctx, l.manager.holder, state); err != nil {
+		return err
+	}
+
+	l.stateMu.Lock()
+	l.state = state
+	l.stateMu.Unlock()
+	return nil
+}
@@ -0,0 +1,121 @@
+package consensustest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// HistoryBackend is a backend that records past tenures.
+type HistoryBackend interface {
+	consensus.Backend
+	consensus.HistoryReader
+}
+
+// HistoryFactory returns a backend over a fresh, empty store. It may be
+// called from parallel subtests.
+type HistoryFactory func(t *testing.T) HistoryBackend
+
+// RunHistorySuite runs the history conformance cases against backends from factory:
+//
+//   - a fresh store has no history
+//   - a release is recorded with its holder, term and times, oldest first
+//   - a holder that stops renewing is recorded as expired, with the
+//     candidate that took over as its successor
+//
+// Cases run in parallel, each with its own backend. The expiry case waits
+// for a one-second lease to lapse.
+func RunHistorySuite(t *testing.T, factory HistoryFactory) {
+	t.Helper()
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, b HistoryBackend)
+	}{
+		{"EmptyHistory", testEmptyHistory},
+		{"ReleaseRecorded", testReleaseRecorded},
+		{"ExpiryRecorded", testExpiryRecorded},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			c.fn(t, factory(t))
+		})
+	}
+}
+
+func testEmptyHistory(t *testing.T, b HistoryBackend) {
+	history, err := b.History(context.Background())
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("history = %+v, want none", history)
+	}
+}
+
+func testReleaseRecorded(t *testing.T, b HistoryBackend) {
+	ctx := context.Background()
+	start := time.Now()
+
+	for _, identity := range []string{"a", "b"} {
+		mustAcquire(t, b, identity, time.Minute)
+		if err := b.Release(ctx, identity); err != nil {
+			t.Fatalf("Release(%s): %v", identity, err)
+		}
+	}
+
+	history, err := b.History(ctx)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history = %+v, want two tenures", history)
+	}
+	for i, identity := range []string{"a", "b"} {
+		tenure := history[i]
+		if tenure.Identity != identity || tenure.Reason != consensus.EndReleased {
+			t.Fatalf("tenure %d = %+v, want %s released", i, tenure, identity)
+		}
+		if tenure.AcquireTime.Before(start.Add(-time.Second)) || tenure.EndTime.Before(tenure.AcquireTime) {
+			t.Fatalf("tenure %d times = %v..%v, want within the test", i, tenure.AcquireTime, tenure.EndTime)
+		}
+	}
+	if history[1].Term <= history[0].Term {
+		t.Fatalf("terms = %d, %d, want increasing", history[0].Term, history[1].Term)
+	}
+}
+
+func testExpiryRecorded(t *testing.T, b HistoryBackend) {
+	ctx := context.Background()
+	mustAcquire(t, b, "a", time.Second)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		acquired, err := b.TryAcquire(ctx, "b", time.Minute)
+		if err != nil {
+			t.Fatalf("TryAcquire(b): %v", err)
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("lease never expired")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	history, err := b.History(ctx)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("history = %+v, want one tenure", history)
+	}
+	if tenure := history[0]; tenure.Identity != "a" || tenure.Reason != consensus.EndExpired || tenure.Successor != "b" {
+		t.Fatalf("tenure = %+v, want a expired with successor b", tenure)
+	}
+}
@@ -0,0 +1,167 @@
+package consensustest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// KVFactory returns a key/value store over a fresh, empty store. It may be
+// called from parallel subtests.
+type KVFactory func(t *testing.T) consensus.KV
+
+// RunKVSuite runs the conformance cases against stores from factory:
+//
+//   - a missing key reads as an entry with an empty version
+//   - CompareAndSwap creates a key only if it is absent
+//   - CompareAndSwap with a stale version fails with consensus.ErrVersionConflict
+//   - Delete checks the version when given one and ignores missing keys
+//   - Watch sends the current entry, then every change including deletion
+//
+// Cases run in parallel, each with its own store.
+func RunKVSuite(t *testing.T, factory KVFactory) {
+	t.Helper()
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, kv consensus.KV)
+	}{
+		{"GetMissing", testGetMissing},
+		{"CreateOnlyIfAbsent", testCreateOnlyIfAbsent},
+		{"StaleSwapConflicts", testStaleSwapConflicts},
+		{"DeleteChecksVersion", testDeleteChecksVersion},
+		{"WatchSeesChanges", testWatchSeesChanges},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			c.fn(t, factory(t))
+		})
+	}
+}
+
+func testGetMissing(t *testing.T, kv consensus.KV) {
+	entry, err := kv.Get(context.Background(), "missing")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if entry.Exists() || entry.Value != nil || entry.Key != "missing" {
+		t.Fatalf("Get(missing) = %+v, want empty entry", entry)
+	}
+}
+
+func testCreateOnlyIfAbsent(t *testing.T, kv consensus.KV) {
+	ctx := context.Background()
+	version := mustSwap(t, kv, "switch", "", "on")
+
+	if _, err := kv.CompareAndSwap(ctx, "switch", "", []byte("off")); !errors.Is(err, consensus.ErrVersionConflict) {
+		t.Fatalf("second create: err = %v, want ErrVersionConflict", err)
+	}
+	mustGet(t, kv, "switch", "on", version)
+}
+
+func testStaleSwapConflicts(t *testing.T, kv consensus.KV) {
+	ctx := context.Background()
+	v1 := mustSwap(t, kv, "cursor", "", "1")
+	v2 := mustSwap(t, kv, "cursor", v1, "2")
+	if v2 == v1 {
+		t.Fatalf("version unchanged after swap: %q", v2)
+	}
+
+	if _, err := kv.CompareAndSwap(ctx, "cursor", v1, []byte("3")); !errors.Is(err, consensus.ErrVersionConflict) {
+		t.Fatalf("stale swap: err = %v, want ErrVersionConflict", err)
+	}
+	if _, err := kv.CompareAndSwap(ctx, "absent", v1, []byte("3")); !errors.Is(err, consensus.ErrVersionConflict) {
+		t.Fatalf("swap of missing key: err = %v, want ErrVersionConflict", err)
+	}
+	mustGet(t, kv, "cursor", "2", v2)
+}
+
+func testDeleteChecksVersion(t *testing.T, kv consensus.KV) {
+	ctx := context.Background()
+	v1 := mustSwap(t, kv, "switch", "", "on")
+	v2 := mustSwap(t, kv, "switch", v1, "off")
+
+	if err := kv.Delete(ctx, "switch", v1); !errors.Is(err, consensus.ErrVersionConflict) {
+		t.Fatalf("stale delete: err = %v, want ErrVersionConflict", err)
+	}
+	if err := kv.Delete(ctx, "switch", v2); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	mustGet(t, kv, "switch", "", "")
+
+	if err := kv.Delete(ctx, "switch", ""); err != nil {
+		t.Fatalf("delete of missing key: %v", err)
+	}
+	mustSwap(t, kv, "switch", "", "on again")
+}
+
+func testWatchSeesChanges(t *testing.T, kv consensus.KV) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := kv.Watch(ctx, "switch")
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if entry := next(t, ch); entry.Exists() {
+		t.Fatalf("initial entry = %+v, want missing", entry)
+	}
+
+	version := mustSwap(t, kv, "switch", "", "on")
+	if entry := next(t, ch); string(entry.Value) != "on" || entry.Version != version {
+		t.Fatalf("entry after create = %+v, want on at %q", entry, version)
+	}
+
+	if err := kv.Delete(ctx, "switch", version); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if entry := next(t, ch); entry.Exists() {
+		t.Fatalf("entry after delete = %+v, want missing", entry)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func mustSwap(t *testing.T, kv consensus.KV, key, oldVersion, value string) string {
+	t.Helper()
+	version, err := kv.CompareAndSwap(context.Background(), key, oldVersion, []byte(value))
+	if err != nil {
+		t.Fatalf("CompareAndSwap(%s, %q): %v", key, oldVersion, err)
+	}
+	if version == "" {
+		t.Fatalf("CompareAndSwap(%s, %q) returned an empty version", key, oldVersion)
+	}
+	return version
+}
+
+func mustGet(t *testing.T, kv consensus.KV, key, value, version string) {
+	t.Helper()
+	entry, err := kv.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", key, err)
+	}
+	if string(entry.Value) != value || entry.Version != version {
+		t.Fatalf("Get(%s) = %q at %q, want %q at %q", key, entry.Value, entry.Version, value, version)
+	}
+}
+
+func next(t *testing.T, ch <-chan consensus.KVEntry) consensus.KVEntry {
+	t.Helper()
+	select {
+	case entry, ok := <-ch:
+		if !ok {
+			t.Fatal("watch channel closed")
+		}
+		return entry
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a watch event")
+		return consensus.KVEntry{}
+	}
+}
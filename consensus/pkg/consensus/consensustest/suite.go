@@ -0,0 +1,188 @@
+// Package consensustest provides a conformance suite for consensus.Backend
+// implementations, so every backend agrees on the behaviour the Manager relies on.
+package consensustest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// Factory returns a backend over a fresh, empty store. Every call within a
+// test must return an independent store; candidates are distinguished by identity.
+// It may be called from parallel subtests.
+type Factory func(t *testing.T) consensus.Backend
+
+// ExpiryLease is the lease duration used by the expiry cases. It is a whole
+// second because some stores (e.g. Kubernetes Leases) only keep seconds.
+const ExpiryLease = time.Second
+
+// heldLease is the lease duration for cases that must not see expiry.
+const heldLease = time.Minute
+
+// RunBackendSuite runs the conformance cases against backends from factory:
+//
+//   - a single candidate acquires a free lease
+//   - a second candidate is blocked while the first holds it
+//   - an expired lease can be taken over
+//   - renewal keeps the lease past its original expiry
+//   - release allows immediate takeover; releasing a lease not held is a no-op
+//   - exactly one of many concurrent candidates wins
+//   - a deposed or never-elected holder's Renew fails with consensus.ErrNotHolder
+//
+// Cases run in parallel, each with its own store.
+func RunBackendSuite(t *testing.T, factory Factory) {
+	t.Helper()
+
+	cases := []struct {
+		name string
+		fn   func(t *testing.T, b consensus.Backend)
+	}{
+		{"SingleAcquire", testSingleAcquire},
+		{"SecondCandidateBlocked", testSecondCandidateBlocked},
+		{"ExpiryAllowsTakeover", testExpiryAllowsTakeover},
+		{"RenewExtendsLease", testRenewExtendsLease},
+		{"ReleaseAllowsTakeover", testReleaseAllowsTakeover},
+		{"ReleaseByNonHolderIsNoop", testReleaseByNonHolderIsNoop},
+		{"ConcurrentAcquire", testConcurrentAcquire},
+		{"RenewWithoutLease", testRenewWithoutLease},
+		{"StaleHolderRenew", testStaleHolderRenew},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			c.fn(t, factory(t))
+		})
+	}
+}
+
+func testSingleAcquire(t *testing.T, b consensus.Backend) {
+	mustAcquire(t, b, "a", heldLease)
+	// Acquiring again as the holder renews
+	mustAcquire(t, b, "a", heldLease)
+}
+
+func testSecondCandidateBlocked(t *testing.T, b consensus.Backend) {
+	mustAcquire(t, b, "a", heldLease)
+	mustNotAcquire(t, b, "b", heldLease)
+}
+
+func testExpiryAllowsTakeover(t *testing.T, b consensus.Backend) {
+	mustAcquire(t, b, "a", ExpiryLease)
+	waitExpiry()
+	mustAcquire(t, b, "b", heldLease)
+}
+
+func testRenewExtendsLease(t *testing.T, b consensus.Backend) {
+	mustAcquire(t, b, "a", ExpiryLease)
+
+	time.Sleep(ExpiryLease * 2 / 3)
+	if err := b.Renew(context.Background(), "a", heldLease); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+
+	waitExpiry()
+	mustNotAcquire(t, b, "b", heldLease)
+}
+
+func testReleaseAllowsTakeover(t *testing.T, b consensus.Backend) {
+	mustAcquire(t, b, "a", heldLease)
+	if err := b.Release(context.Background(), "a"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	mustAcquire(t, b, "b", heldLease)
+}
+
+func testReleaseByNonHolderIsNoop(t *testing.T, b consensus.Backend) {
+	ctx := context.Background()
+
+	if err := b.Release(ctx, "a"); err != nil {
+		t.Fatalf("release of a free lease: %v", err)
+	}
+
+	mustAcquire(t, b, "a", heldLease)
+	if err := b.Release(ctx, "b"); err != nil {
+		t.Fatalf("release by non-holder: %v", err)
+	}
+	mustNotAcquire(t, b, "b", heldLease)
+}
+
+func testConcurrentAcquire(t *testing.T, b consensus.Backend) {
+	const candidates = 8
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var winners []string
+	var errs []error
+	for i := range candidates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			identity := fmt.Sprintf("candidate-%d", i)
+			ok, err := b.TryAcquire(context.Background(), identity, heldLease)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if ok {
+				winners = append(winners, identity)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		t.Fatalf("concurrent acquire errors: %v", errors.Join(errs...))
+	}
+	if len(winners) != 1 {
+		t.Fatalf("winners = %v, want exactly one", winners)
+	}
+}
+
+func testRenewWithoutLease(t *testing.T, b consensus.Backend) {
+	if err := b.Renew(context.Background(), "a", heldLease); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("renew of a lease never acquired: got %v, want ErrNotHolder", err)
+	}
+}
+
+func testStaleHolderRenew(t *testing.T, b consensus.Backend) {
+	mustAcquire(t, b, "a", ExpiryLease)
+	waitExpiry()
+	mustAcquire(t, b, "b", heldLease)
+
+	if err := b.Renew(context.Background(), "a", heldLease); !errors.Is(err, consensus.ErrNotHolder) {
+		t.Fatalf("renew by deposed holder: got %v, want ErrNotHolder", err)
+	}
+}
+
+func mustAcquire(t *testing.T, b consensus.Backend, identity string, d time.Duration) {
+	t.Helper()
+	if ok, err := b.TryAcquire(context.Background(), identity, d); err != nil || !ok {
+		t.Fatalf("%s: acquire: ok=%v err=%v", identity, ok, err)
+	}
+}
+
+func mustNotAcquire(t *testing.T, b consensus.Backend, identity string, d time.Duration) {
+	t.Helper()
+	ok, err := b.TryAcquire(context.Background(), identity, d)
+	if err != nil {
+		t.Fatalf("%s: acquire: %v", identity, err)
+	}
+	if ok {
+		t.Fatalf("%s: acquired a lease held by another candidate", identity)
+	}
+}
+
+// waitExpiry sleeps until a lease of ExpiryLease taken now has lapsed,
+// with margin for stores that round or add clock-drift allowance.
+func waitExpiry() {
+	time.Sleep(ExpiryLease + ExpiryLease/2)
+}
@@ -0,0 +1,71 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnhealthy indicates leadership was given up because Config.HealthCheck kept failing.
+var ErrUnhealthy = errors.New("health check failing")
+
+// DefaultHealthFailureThreshold is how many consecutive failed health checks
+// make a leader step down when Config.HealthFailureThreshold is zero.
+const DefaultHealthFailureThreshold = 3
+
+// Status is a snapshot of a Lease's leadership and health.
+type Status struct {
+	IsLeader       bool
+	Term           uint64
+	Healthy        bool  // True if the last health check passed, or none is configured
+	HealthError    error // Error from the last health check, if it failed
+	HealthFailures int   // Consecutive failed health checks
+}
+
+// Status returns the current leadership and health of this instance.
+func (l *Lease) Status() Status {
+	l.healthMu.Lock()
+	defer l.healthMu.Unlock()
+
+	return Status{
+		IsLeader:       l.IsLeader(),
+		Term:           l.Term(),
+		Healthy:        l.healthErr == nil,
+		HealthError:    l.healthErr,
+		HealthFailures: l.healthFailures,
+	}
+}
+
+// checkHealth runs the configured health check and records the result.
+// It returns the number of consecutive failures, or 0 if the check passed.
+func (m *Manager) checkHealth(ctx context.Context) (int, error) {
+	if m.config.HealthCheck == nil {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	err := m.config.HealthCheck(ctx)
+	cancel()
+	if err != nil {
+		err = fmt.Errorf("%w: %w", ErrUnhealthy, err)
+	}
+
+	m.lease.healthMu.Lock()
+	defer m.lease.healthMu.Unlock()
+
+	m.lease.healthErr = err
+	if err == nil {
+		m.lease.healthFailures = 0
+	} else {
+		m.lease.healthFailures++
+	}
+	return m.lease.healthFailures, err
+}
+
+// healthFailureThreshold returns the configured threshold or its default.
+func (m *Manager) healthFailureThreshold() int {
+	if m.config.HealthFailureThreshold > 0 {
+		return m.config.HealthFailureThreshold
+	}
+	return DefaultHealthFailureThreshold
+}
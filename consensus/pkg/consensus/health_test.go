@@ -0,0 +1,99 @@
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+func fastConfig(identity string) consensus.Config {
+	return consensus.Config{
+		Identity:      identity,
+		LeaseDuration: time.Second,
+		RenewInterval: 20 * time.Millisecond,
+		RetryInterval: 20 * time.Millisecond,
+	}
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUnhealthyInstanceDoesNotCampaign(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+
+	var healthy atomic.Bool
+	config := fastConfig("a")
+	config.HealthCheck = func(context.Context) error {
+		if !healthy.Load() {
+			return errors.New("database unreachable")
+		}
+		return nil
+	}
+
+	manager := consensus.NewManager(backend, config)
+	lease := manager.Start(context.Background())
+	defer manager.Stop()
+
+	eventually(t, "a failed health check", func() bool { return lease.Status().HealthFailures >= 3 })
+	if status := lease.Status(); status.IsLeader || status.Healthy || !errors.Is(status.HealthError, consensus.ErrUnhealthy) {
+		t.Fatalf("status = %+v, want unhealthy follower", status)
+	}
+
+	healthy.Store(true)
+	eventually(t, "leadership once healthy", lease.IsLeader)
+}
+
+func TestUnhealthyLeaderStepsDown(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+
+	var healthy atomic.Bool
+	healthy.Store(true)
+	var released atomic.Pointer[consensus.Event]
+
+	config := fastConfig("a")
+	config.HealthFailureThreshold = 2
+	config.HealthCheck = func(context.Context) error {
+		if !healthy.Load() {
+			return errors.New("database unreachable")
+		}
+		return nil
+	}
+	config.Hooks = []consensus.Hook{consensus.HookFunc(func(_ context.Context, e consensus.Event) {
+		if e.Type == consensus.EventReleased {
+			released.Store(&e)
+		}
+	})}
+
+	manager := consensus.NewManager(backend, config)
+	lease := manager.Start(context.Background())
+	defer manager.Stop()
+
+	eventually(t, "leadership", lease.IsLeader)
+	healthy.Store(false)
+	eventually(t, "step-down", func() bool { return !lease.IsLeader() })
+
+	if e := released.Load(); e == nil || !errors.Is(e.Err, consensus.ErrUnhealthy) {
+		t.Fatalf("released event = %+v, want ErrUnhealthy cause", e)
+	}
+	leader, err := backend.GetLeader(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader != nil {
+		t.Fatalf("lease still held by %q after step-down", leader.Identity)
+	}
+}
@@ -0,0 +1,80 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultHistoryLimit is how many past tenures backends keep by default.
+const DefaultHistoryLimit = 32
+
+// EndReason says why a holder stopped leading.
+type EndReason string
+
+const (
+	// EndReleased means the holder released the lease, e.g. on shutdown or StepDown.
+	EndReleased EndReason = "released"
+	// EndExpired means the holder stopped renewing and another candidate took the lease over.
+	EndExpired EndReason = "expired"
+	// EndPreempted means an operator cleared the lease while it was held.
+	EndPreempted EndReason = "preempted"
+	// EndTransferred means an operator handed the lease to another identity.
+	EndTransferred EndReason = "transferred"
+)
+
+// Tenure is one completed period of leadership, as recorded by a HistoryReader backend.
+type Tenure struct {
+	Identity    string    `json:"holder"`         // Holder ID (as stored by backends)
+	Session     string    `json:"-"`              // Session ID; set by Manager.History, which splits it from Identity
+	Term        uint64    `json:"term"`           // LeaderRecord.Transitions while this holder led
+	AcquireTime time.Time `json:"acquireTime"`    // When the holder acquired the lease
+	EndTime     time.Time `json:"endTime"`        // When the holder stopped leading; for EndExpired, when the lease lapsed
+	Reason      EndReason `json:"reason"`         // Why the holder stopped
+	Successor   string    `json:"next,omitempty"` // Holder that took over, if known when the tenure ended
+}
+
+// History is a bounded record of past tenures, oldest first. Backends store
+// it alongside the lease and use Append so they trim it the same way.
+type History []Tenure
+
+// Append adds t and drops the oldest tenures beyond limit.
+// A limit of zero or less means DefaultHistoryLimit.
+func (h History) Append(t Tenure, limit int) History {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	h = append(h, t)
+	if len(h) > limit {
+		h = append(History(nil), h[len(h)-limit:]...)
+	}
+	return h
+}
+
+// HistoryReader is implemented by backends that record past tenures. Every
+// backend in this module does: file, Lease, objectstore, nats and etcd
+// persist the history with the lease, so it survives restarts; remote asks
+// the lock server's backend; majority merges the tenures a majority of its
+// stores recorded; and quorum keeps each voter's view in memory only.
+type HistoryReader interface {
+	// History returns the recorded tenures, oldest first.
+	History(ctx context.Context) ([]Tenure, error)
+}
+
+// History returns the recorded past tenures, oldest first.
+func (m *Manager) History(ctx context.Context) ([]Tenure, error) {
+	hr, ok := m.backend.(HistoryReader)
+	if !ok {
+		return nil, fmt.Errorf("%w: backend does not record history", errors.ErrUnsupported)
+	}
+
+	history, err := hr.History(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		history[i].Identity, history[i].Session = SplitHolder(history[i].Identity)
+	}
+	return history, nil
+}
@@ -0,0 +1,35 @@
+package consensus_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+func TestHistoryRecordsStepDown(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	ctx := context.Background()
+
+	manager := consensus.NewManager(backend, fastConfig("a"))
+	lease := manager.Start(ctx)
+	defer manager.Stop()
+	eventually(t, "leadership", lease.IsLeader)
+
+	manager.StepDown()
+	eventually(t, "step-down", func() bool { return !lease.IsLeader() })
+
+	history, err := manager.History(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, session := consensus.SplitHolder(manager.HolderID())
+	if len(history) != 1 || history[0].Identity != "a" || history[0].Session != session || history[0].Reason != consensus.EndReleased {
+		t.Fatalf("history = %+v, want a's session released", history)
+	}
+	if !history[0].EndTime.After(history[0].AcquireTime) {
+		t.Fatalf("tenure ends at %v, before it began at %v", history[0].EndTime, history[0].AcquireTime)
+	}
+}
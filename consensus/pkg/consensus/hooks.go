@@ -0,0 +1,61 @@
+package consensus
+
+import (
+	"context"
+)
+
+// EventType identifies a leadership transition.
+type EventType string
+
+const (
+	// EventAcquired is emitted when this instance becomes leader.
+	EventAcquired EventType = "Acquired"
+	// EventLost is emitted when leadership is lost without releasing it,
+	// e.g. after consecutive renewal failures or a transfer.
+	EventLost EventType = "Lost"
+	// EventRenewFailed is emitted for each failed renewal while leading.
+	// A second consecutive failure also demotes the instance and is
+	// followed by EventLost.
+	EventRenewFailed EventType = "RenewFailed"
+	// EventReleased is emitted when this instance gives up leadership on
+	// shutdown or StepDown.
+	EventReleased EventType = "Released"
+)
+
+// Event describes a leadership transition observed by a Manager.
+type Event struct {
+	Type     EventType
+	Identity string
+	Err      error // Cause of the transition, if any
+}
+
+// Hook observes leadership transitions of a Manager.
+// Hooks run synchronously on the election loop with a bounded context,
+// so they should return promptly.
+type Hook interface {
+	OnLeadershipEvent(ctx context.Context, event Event)
+}
+
+// HookFunc adapts a function to the Hook interface.
+type HookFunc func(ctx context.Context, event Event)
+
+// OnLeadershipEvent calls f.
+func (f HookFunc) OnLeadershipEvent(ctx context.Context, event Event) {
+	f(ctx, event)
+}
+
+// emit delivers an event to every configured hook.
+func (m *Manager) emit(eventType EventType, err error) {
+	event := Event{
+		Type:     eventType,
+		Identity: m.config.Identity,
+		Err:      err,
+	}
+	m.logEvent(event)
+
+	for _, hook := range m.config.Hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+		hook.OnLeadershipEvent(ctx, event)
+		cancel()
+	}
+}
@@ -0,0 +1,174 @@
+// Package httpfwd provides HTTP middleware that serves requests locally when
+// this instance is the leader and reverse-proxies them to the advertised
+// leader address otherwise.
+package httpfwd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// ForwardedHeader marks a request that has already been forwarded to the leader.
+// It carries the identity of the forwarding instance and prevents forwarding loops.
+const ForwardedHeader = "X-Consensus-Forwarded-By"
+
+// Elector is the subset of consensus.Manager used by the middleware.
+type Elector interface {
+	Identity() string
+	HolderID() string
+	IsLeader() bool
+	Leader(ctx context.Context) (*consensus.LeaderRecord, error)
+}
+
+// Option configures a Forwarder.
+type Option func(*Forwarder)
+
+// WithNoLeaderStatus sets the status returned when there is no reachable leader
+// (default: 503 Service Unavailable).
+func WithNoLeaderStatus(status int) Option {
+	return func(f *Forwarder) {
+		f.noLeaderStatus = status
+	}
+}
+
+// WithTransport sets the transport used to proxy requests to the leader.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(f *Forwarder) {
+		f.transport = transport
+	}
+}
+
+// WithCacheTTL sets how long a leader lookup is reused before asking the
+// backend again (default: 1s). Zero disables caching.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(f *Forwarder) {
+		f.cacheTTL = ttl
+	}
+}
+
+// Forwarder is an http.Handler that routes requests to the leader.
+type Forwarder struct {
+	elector        Elector
+	next           http.Handler
+	noLeaderStatus int
+	transport      http.RoundTripper
+	cacheTTL       time.Duration
+
+	mu       sync.Mutex
+	cached   *consensus.LeaderRecord
+	cachedAt time.Time
+	proxies  map[string]*httputil.ReverseProxy
+}
+
+// New wraps next so that it only runs on the leader. Followers proxy the
+// request to the address the leader advertised via Config.AdvertiseAddress.
+func New(elector Elector, next http.Handler, opts ...Option) *Forwarder {
+	f := &Forwarder{
+		elector:        elector,
+		next:           next,
+		noLeaderStatus: http.StatusServiceUnavailable,
+		cacheTTL:       time.Second,
+		proxies:        make(map[string]*httputil.ReverseProxy),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// ServeHTTP implements http.Handler.
+func (f *Forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.elector.IsLeader() {
+		f.next.ServeHTTP(w, r)
+		return
+	}
+
+	// A request that was already forwarded must not be forwarded again,
+	// otherwise two instances with stale views would bounce it between them.
+	if r.Header.Get(ForwardedHeader) != "" {
+		http.Error(w, "not the leader", f.noLeaderStatus)
+		return
+	}
+
+	// Compare holder IDs: another process may share this instance's identity
+	record := f.leader(r.Context())
+	if record == nil || record.Address == "" || consensus.HolderID(record.Identity, record.Session) == f.elector.HolderID() {
+		http.Error(w, "no leader available", f.noLeaderStatus)
+		return
+	}
+
+	proxy, err := f.proxy(record.Address)
+	if err != nil {
+		http.Error(w, "invalid leader address", http.StatusBadGateway)
+		return
+	}
+
+	proxy.ServeHTTP(w, r)
+}
+
+// leader returns the current leader record, reusing a recent lookup.
+func (f *Forwarder) leader(ctx context.Context) *consensus.LeaderRecord {
+	f.mu.Lock()
+	if f.cacheTTL > 0 && time.Since(f.cachedAt) < f.cacheTTL {
+		record := f.cached
+		f.mu.Unlock()
+		return record
+	}
+	f.mu.Unlock()
+
+	// Lookup failures are treated as "no leader" so the fallback status is served
+	record, err := f.elector.Leader(ctx)
+	if err != nil {
+		record = nil
+	}
+
+	f.mu.Lock()
+	f.cached = record
+	f.cachedAt = time.Now()
+	f.mu.Unlock()
+
+	return record
+}
+
+// proxy returns a reverse proxy for the given leader address.
+// Addresses without a scheme are treated as plain HTTP host:port.
+func (f *Forwarder) proxy(address string) (*httputil.ReverseProxy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if proxy, ok := f.proxies[address]; ok {
+		return proxy, nil
+	}
+
+	raw := address
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	target, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := f.elector.Identity()
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.Header.Set(ForwardedHeader, identity)
+		},
+		Transport: f.transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "leader unreachable", http.StatusBadGateway)
+		},
+	}
+	f.proxies[address] = proxy
+
+	return proxy, nil
+}
@@ -0,0 +1,160 @@
+package httpfwd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+type stubElector struct {
+	identity string
+	leader   bool
+	record   *consensus.LeaderRecord
+}
+
+func (s *stubElector) Identity() string { return s.identity }
+func (s *stubElector) HolderID() string { return s.identity }
+func (s *stubElector) IsLeader() bool   { return s.leader }
+func (s *stubElector) Leader(ctx context.Context) (*consensus.LeaderRecord, error) {
+	return s.record, nil
+}
+
+func handlerNamed(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Served-By", name)
+		w.Header().Set("X-Seen-Forwarded-By", r.Header.Get(ForwardedHeader))
+		io.WriteString(w, name)
+	})
+}
+
+func TestLeaderServesLocally(t *testing.T) {
+	f := New(&stubElector{identity: "a", leader: true}, handlerNamed("a"))
+
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/write", nil))
+
+	if got := rec.Header().Get("X-Served-By"); got != "a" {
+		t.Fatalf("served by %q, want a", got)
+	}
+}
+
+func TestFollowerForwardsToLeader(t *testing.T) {
+	leader := httptest.NewServer(New(&stubElector{identity: "a", leader: true}, handlerNamed("a")))
+	defer leader.Close()
+
+	follower := New(&stubElector{
+		identity: "b",
+		record:   &consensus.LeaderRecord{Identity: "a", Address: leader.URL},
+	}, handlerNamed("b"))
+
+	rec := httptest.NewRecorder()
+	follower.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/write", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("X-Served-By"); got != "a" {
+		t.Fatalf("served by %q, want a", got)
+	}
+	if got := rec.Header().Get("X-Seen-Forwarded-By"); got != "b" {
+		t.Fatalf("leader saw forwarded-by %q, want b", got)
+	}
+}
+
+func TestForwardedRequestIsNotForwardedAgain(t *testing.T) {
+	// Both instances believe the other leads; the request must not bounce.
+	var a, b *Forwarder
+	serverA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { a.ServeHTTP(w, r) }))
+	defer serverA.Close()
+	serverB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { b.ServeHTTP(w, r) }))
+	defer serverB.Close()
+
+	a = New(&stubElector{identity: "a", record: &consensus.LeaderRecord{Identity: "b", Address: serverB.URL}}, handlerNamed("a"))
+	b = New(&stubElector{identity: "b", record: &consensus.LeaderRecord{Identity: "a", Address: serverA.URL}}, handlerNamed("b"))
+
+	resp, err := http.Post(serverA.URL+"/write", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503", resp.StatusCode)
+	}
+}
+
+func TestNoLeaderStatus(t *testing.T) {
+	f := New(&stubElector{identity: "b"}, handlerNamed("b"), WithNoLeaderStatus(http.StatusMisdirectedRequest))
+
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/write", nil))
+
+	if rec.Code != http.StatusMisdirectedRequest {
+		t.Fatalf("status %d, want 421", rec.Code)
+	}
+}
+
+func TestFollowerSharingIdentityForwards(t *testing.T) {
+	// Two processes on one host campaign under the same identity
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	var first, second *Forwarder
+	serverFirst := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { first.ServeHTTP(w, r) }))
+	defer serverFirst.Close()
+	serverSecond := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { second.ServeHTTP(w, r) }))
+	defer serverSecond.Close()
+
+	newManager := func(address string) *consensus.Manager {
+		config := consensus.NewConfig("web")
+		config.LeaseDuration = time.Second
+		config.RenewInterval = 100 * time.Millisecond
+		config.RetryInterval = 20 * time.Millisecond
+		config.AdvertiseAddress = address
+		return consensus.NewManager(backend, config)
+	}
+	managerFirst := newManager(serverFirst.URL)
+	managerSecond := newManager(serverSecond.URL)
+	first = New(managerFirst, handlerNamed("first"), WithCacheTTL(0))
+	second = New(managerSecond, handlerNamed("second"), WithCacheTTL(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := managerFirst.Start(ctx).WaitForLeadership(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer managerFirst.Stop()
+	managerSecond.Start(ctx)
+	defer managerSecond.Stop()
+
+	// The advertised address is written just after acquiring
+	for {
+		record, err := managerSecond.Leader(ctx)
+		if err == nil && record != nil && record.Address != "" {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("leader address never advertised")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	resp, err := http.Post(serverSecond.URL+"/write", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("X-Served-By"); got != "first" {
+		t.Fatalf("served by %q, want first", got)
+	}
+}
@@ -0,0 +1,47 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrVersionConflict indicates a KV write whose expected version no longer matches the stored one.
+var ErrVersionConflict = errors.New("version conflict")
+
+// KVEntry is a value stored under a key, with the version needed to change it.
+type KVEntry struct {
+	Key     string
+	Value   []byte // nil if the key does not exist
+	Version string // Opaque version; empty if the key does not exist
+}
+
+// Exists reports whether the key was present.
+func (e KVEntry) Exists() bool {
+	return e.Version != ""
+}
+
+// KV is a small key/value store built on a backend's atomic read-modify-write,
+// for coordinated config such as feature switches or cursor positions.
+// Backends that support it return one from a KV method (e.g. file.Backend.KV)
+// rather than implementing it themselves, as KV.Watch would collide with
+// Watcher. Versions are opaque and only comparable within one store.
+type KV interface {
+	// Get returns the entry stored under key. A missing key is returned as an
+	// entry with an empty version, not an error.
+	Get(ctx context.Context, key string) (KVEntry, error)
+
+	// CompareAndSwap stores value under key if its current version is
+	// oldVersion, and returns the new version. An empty oldVersion requires
+	// the key not to exist. Returns an error wrapping ErrVersionConflict otherwise.
+	CompareAndSwap(ctx context.Context, key, oldVersion string, value []byte) (string, error)
+
+	// Delete removes key if its current version is version, or whatever its
+	// version if version is empty. Deleting a missing key is a no-op.
+	// Returns an error wrapping ErrVersionConflict on a version mismatch.
+	Delete(ctx context.Context, key, version string) error
+
+	// Watch streams the entry under key whenever it changes, starting with the
+	// current one. A deleted key is sent as an entry with an empty version.
+	// The channel is closed when ctx is cancelled or the watch fails.
+	Watch(ctx context.Context, key string) (<-chan KVEntry, error)
+}
@@ -0,0 +1,66 @@
+package lockserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"connectrpc.com/connect"
+)
+
+// errUnauthenticated is returned when a request carries no valid bearer token.
+var errUnauthenticated = errors.New("invalid or missing bearer token")
+
+// authInterceptor rejects requests without one of the configured bearer tokens.
+type authInterceptor struct {
+	tokens []string
+}
+
+func newAuthInterceptor(tokens []string) *authInterceptor {
+	return &authInterceptor{tokens: tokens}
+}
+
+// WrapUnary checks the token on unary calls.
+func (a *authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if !req.Spec().IsClient && !a.authorized(req.Header().Get("Authorization")) {
+			return nil, connect.NewError(connect.CodeUnauthenticated, errUnauthenticated)
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient is a no-op; the interceptor is only installed on the server.
+func (a *authInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler checks the token on streaming calls.
+func (a *authInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if !a.authorized(conn.RequestHeader().Get("Authorization")) {
+			return connect.NewError(connect.CodeUnauthenticated, errUnauthenticated)
+		}
+		return next(ctx, conn)
+	}
+}
+
+// authorized reports whether the Authorization header carries a known token.
+func (a *authInterceptor) authorized(header string) bool {
+	if len(a.tokens) == 0 {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return false
+	}
+
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,402 @@
+// Package lockserver exposes a consensus.Backend over the LockService
+// Connect/gRPC API, so processes without direct access to the store (other
+// runtimes, hosts without cluster credentials) can share an election.
+package lockserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	consensusv1 "github.com/fraser/consensus/gen/consensus/v1"
+	"github.com/fraser/consensus/gen/consensus/v1/consensusv1connect"
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+const (
+	// DefaultSessionTTL is how long a disconnected session is kept before its leases are released.
+	DefaultSessionTTL = 10 * time.Second
+	// DefaultWatchInterval is how often Watch polls the backend for leader changes.
+	DefaultWatchInterval = time.Second
+
+	// minHeartbeatInterval bounds how often KeepAlive sends heartbeats, however short the TTL.
+	minHeartbeatInterval = 10 * time.Millisecond
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithTokens requires clients to present one of the given bearer tokens.
+// With no tokens configured, the server accepts unauthenticated requests.
+func WithTokens(tokens ...string) Option {
+	return func(s *Server) {
+		for _, t := range tokens {
+			if t != "" {
+				s.tokens = append(s.tokens, t)
+			}
+		}
+	}
+}
+
+// WithMaxSessionTTL caps the session TTL a client may request (default: DefaultSessionTTL).
+// A non-positive ttl keeps the default.
+func WithMaxSessionTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.maxSessionTTL = ttl
+	}
+}
+
+// WithWatchInterval sets how often Watch polls the backend (default: DefaultWatchInterval).
+func WithWatchInterval(d time.Duration) Option {
+	return func(s *Server) {
+		s.watchInterval = d
+	}
+}
+
+// Server implements consensusv1connect.LockServiceHandler on top of a backend.
+type Server struct {
+	backend       consensus.Backend
+	tokens        []string
+	maxSessionTTL time.Duration
+	watchInterval time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session tracks the identities acquired by one client and its open KeepAlive streams.
+type session struct {
+	identities map[string]struct{}
+	streams    int
+	expiry     *time.Timer
+	generation int // Bumped whenever expiry is rearmed or cancelled
+}
+
+var _ consensusv1connect.LockServiceHandler = (*Server)(nil)
+
+// New creates a server for backend.
+func New(backend consensus.Backend, opts ...Option) *Server {
+	s := &Server{
+		backend:       backend,
+		maxSessionTTL: DefaultSessionTTL,
+		watchInterval: DefaultWatchInterval,
+		sessions:      make(map[string]*session),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxSessionTTL <= 0 {
+		s.maxSessionTTL = DefaultSessionTTL
+	}
+	return s
+}
+
+// Handler returns the mount path and HTTP handler for the LockService.
+func (s *Server) Handler() (string, http.Handler) {
+	return consensusv1connect.NewLockServiceHandler(s, connect.WithInterceptors(newAuthInterceptor(s.tokens)))
+}
+
+// TryAcquire attempts to acquire or renew leadership.
+func (s *Server) TryAcquire(ctx context.Context, req *connect.Request[consensusv1.TryAcquireRequest]) (*connect.Response[consensusv1.TryAcquireResponse], error) {
+	if req.Msg.Identity == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("identity is required"))
+	}
+
+	acquired, err := s.backend.TryAcquire(ctx, req.Msg.Identity, req.Msg.LeaseDuration.AsDuration())
+	if err != nil {
+		return nil, toConnectError(err)
+	}
+	if acquired {
+		s.track(req.Msg.SessionId, req.Msg.Identity, req.Msg.LeaseDuration.AsDuration())
+	}
+
+	return connect.NewResponse(&consensusv1.TryAcquireResponse{Acquired: acquired}), nil
+}
+
+// Renew extends the current leader's lease.
+func (s *Server) Renew(ctx context.Context, req *connect.Request[consensusv1.RenewRequest]) (*connect.Response[consensusv1.RenewResponse], error) {
+	if err := s.backend.Renew(ctx, req.Msg.Identity, req.Msg.LeaseDuration.AsDuration()); err != nil {
+		return nil, toConnectError(err)
+	}
+	s.track(req.Msg.SessionId, req.Msg.Identity, req.Msg.LeaseDuration.AsDuration())
+
+	return connect.NewResponse(&consensusv1.RenewResponse{}), nil
+}
+
+// Release explicitly gives up leadership.
+func (s *Server) Release(ctx context.Context, req *connect.Request[consensusv1.ReleaseRequest]) (*connect.Response[consensusv1.ReleaseResponse], error) {
+	if err := s.backend.Release(ctx, req.Msg.Identity); err != nil {
+		return nil, toConnectError(err)
+	}
+	s.untrack(req.Msg.SessionId, req.Msg.Identity)
+
+	return connect.NewResponse(&consensusv1.ReleaseResponse{}), nil
+}
+
+// SetAddress records the address at which the lease holder serves requests.
+func (s *Server) SetAddress(ctx context.Context, req *connect.Request[consensusv1.SetAddressRequest]) (*connect.Response[consensusv1.SetAddressResponse], error) {
+	aa, ok := s.backend.(consensus.AddressAdvertiser)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("backend does not store addresses"))
+	}
+
+	if err := aa.SetAddress(ctx, req.Msg.Identity, req.Msg.Address); err != nil {
+		return nil, toConnectError(err)
+	}
+
+	return connect.NewResponse(&consensusv1.SetAddressResponse{}), nil
+}
+
+// GetLeader returns the current lease holder.
+func (s *Server) GetLeader(ctx context.Context, req *connect.Request[consensusv1.GetLeaderRequest]) (*connect.Response[consensusv1.GetLeaderResponse], error) {
+	leader, err := s.leader(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return connect.NewResponse(&consensusv1.GetLeaderResponse{Leader: leader}), nil
+}
+
+// History returns the recorded past tenures, oldest first.
+func (s *Server) History(ctx context.Context, req *connect.Request[consensusv1.HistoryRequest]) (*connect.Response[consensusv1.HistoryResponse], error) {
+	hr, ok := s.backend.(consensus.HistoryReader)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("backend does not record history"))
+	}
+
+	history, err := hr.History(ctx)
+	if err != nil {
+		return nil, toConnectError(err)
+	}
+
+	tenures := make([]*consensusv1.Tenure, len(history))
+	for i, t := range history {
+		tenures[i] = &consensusv1.Tenure{
+			Identity:    t.Identity,
+			Term:        t.Term,
+			AcquireTime: timestamppb.New(t.AcquireTime),
+			EndTime:     timestamppb.New(t.EndTime),
+			Reason:      string(t.Reason),
+			Successor:   t.Successor,
+		}
+	}
+
+	return connect.NewResponse(&consensusv1.HistoryResponse{Tenures: tenures}), nil
+}
+
+// Watch streams the lease holder whenever it changes.
+// The first message carries the current holder.
+func (s *Server) Watch(ctx context.Context, req *connect.Request[consensusv1.WatchRequest], stream *connect.ServerStream[consensusv1.WatchResponse]) error {
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	first := true
+	var last string
+	for {
+		leader, err := s.leader(ctx)
+		if err != nil {
+			return err
+		}
+
+		key := leaderKey(leader)
+		if first || key != last {
+			if err := stream.Send(&consensusv1.WatchResponse{Leader: leader}); err != nil {
+				return err
+			}
+			first = false
+			last = key
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// KeepAlive holds a session open until the client disconnects.
+// Heartbeats are sent at a third of the TTL so the client can detect a dead server.
+func (s *Server) KeepAlive(ctx context.Context, req *connect.Request[consensusv1.KeepAliveRequest], stream *connect.ServerStream[consensusv1.KeepAliveResponse]) error {
+	id := req.Msg.SessionId
+	if id == "" {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New("session_id is required"))
+	}
+
+	ttl := req.Msg.Ttl.AsDuration()
+	if ttl <= 0 || ttl > s.maxSessionTTL {
+		ttl = s.maxSessionTTL
+	}
+
+	s.connect(id)
+	defer s.disconnect(id, ttl)
+
+	ticker := time.NewTicker(max(ttl/3, minHeartbeatInterval))
+	defer ticker.Stop()
+
+	for {
+		if err := stream.Send(&consensusv1.KeepAliveResponse{Time: timestamppb.Now()}); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// leader reads the current holder from the backend.
+func (s *Server) leader(ctx context.Context) (*consensusv1.Leader, error) {
+	lr, ok := s.backend.(consensus.LeaderReader)
+	if !ok {
+		return nil, connect.NewError(connect.CodeUnimplemented, errors.New("backend does not report the leader"))
+	}
+
+	record, err := lr.GetLeader(ctx)
+	if err != nil {
+		return nil, toConnectError(err)
+	}
+	if record == nil {
+		return nil, nil
+	}
+
+	return &consensusv1.Leader{
+		Identity:      record.Identity,
+		Address:       record.Address,
+		AcquireTime:   timestamppb.New(record.AcquireTime),
+		RenewTime:     timestamppb.New(record.RenewTime),
+		LeaseDuration: durationpb.New(record.LeaseDuration),
+		Transitions:   record.Transitions,
+	}, nil
+}
+
+// track records that identity holds a lease of leaseDuration through the
+// session. A session without an open KeepAlive stream expires after the
+// longer of the lease duration and the maximum session TTL unless the client
+// calls again, so clients that never open one are not tracked forever but
+// keep their lease as long as they renew within it.
+func (s *Server) track(id, identity string, leaseDuration time.Duration) {
+	if id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess := s.session(id)
+	sess.identities[identity] = struct{}{}
+	if sess.streams == 0 {
+		s.scheduleExpiry(id, sess, max(leaseDuration, s.maxSessionTTL))
+	}
+}
+
+// untrack removes identity from the session after a release.
+func (s *Server) untrack(id, identity string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sess, ok := s.sessions[id]; ok {
+		delete(sess.identities, identity)
+	}
+}
+
+// connect registers an open KeepAlive stream and cancels any pending expiry.
+func (s *Server) connect(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess := s.session(id)
+	sess.streams++
+	sess.generation++
+	if sess.expiry != nil {
+		sess.expiry.Stop()
+		sess.expiry = nil
+	}
+}
+
+// disconnect unregisters a KeepAlive stream. When the last stream closes,
+// the session expires after ttl unless the client reconnects.
+func (s *Server) disconnect(id string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess := s.session(id)
+	sess.streams--
+	if sess.streams > 0 {
+		return
+	}
+	s.scheduleExpiry(id, sess, ttl)
+}
+
+// scheduleExpiry (re)arms the session's expiry timer. Caller holds s.mu.
+func (s *Server) scheduleExpiry(id string, sess *session, ttl time.Duration) {
+	if sess.expiry != nil {
+		sess.expiry.Stop()
+	}
+	sess.generation++
+	generation := sess.generation
+	sess.expiry = time.AfterFunc(ttl, func() { s.expire(id, sess, generation) })
+}
+
+// expire releases every lease held through the session and forgets it,
+// unless the session was reconnected or its expiry rearmed since generation.
+func (s *Server) expire(id string, sess *session, generation int) {
+	s.mu.Lock()
+	if s.sessions[id] != sess || sess.streams > 0 || sess.generation != generation {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.sessions, id)
+	identities := make([]string, 0, len(sess.identities))
+	for identity := range sess.identities {
+		identities = append(identities, identity)
+	}
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, identity := range identities {
+		_ = s.backend.Release(ctx, identity)
+	}
+}
+
+// session returns the session with id, creating it if needed. Caller holds s.mu.
+func (s *Server) session(id string) *session {
+	sess, ok := s.sessions[id]
+	if !ok {
+		sess = &session{identities: make(map[string]struct{})}
+		s.sessions[id] = sess
+	}
+	return sess
+}
+
+// leaderKey identifies a leader for change detection.
+func leaderKey(leader *consensusv1.Leader) string {
+	if leader == nil {
+		return ""
+	}
+	return leader.Identity + "\x00" + leader.Address
+}
+
+// toConnectError maps backend errors to Connect codes.
+func toConnectError(err error) error {
+	switch {
+	case errors.Is(err, consensus.ErrNotHolder):
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	case errors.Is(err, context.Canceled):
+		return connect.NewError(connect.CodeCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return connect.NewError(connect.CodeDeadlineExceeded, err)
+	default:
+		return connect.NewError(connect.CodeUnavailable, fmt.Errorf("backend: %w", err))
+	}
+}
@@ -0,0 +1,104 @@
+package consensus
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Member is one participant in the election as recorded by a Membership backend.
+type Member struct {
+	Identity string        // Holder ID of the member (as stored by backends)
+	Session  string        // Session ID of the member; set by Manager.Members, which splits it from Identity
+	Address  string        // Address the member advertises, empty if none
+	Priority int           // Config.Priority of the member
+	Version  string        // Config.Version of the member
+	LastSeen time.Time     // When the member last heartbeated
+	TTL      time.Duration // How long the record lives after LastSeen
+}
+
+// Expired reports whether the member has missed its heartbeats at now.
+func (m Member) Expired(now time.Time) bool {
+	return now.Sub(m.LastSeen) > m.TTL
+}
+
+// Membership is implemented by backends that can keep a record for every
+// participant in the election, not only the leader.
+type Membership interface {
+	// Heartbeat creates or refreshes the record for member.Identity.
+	Heartbeat(ctx context.Context, member Member) error
+
+	// Leave removes the record for holder. Removing an absent record is a no-op.
+	Leave(ctx context.Context, holder string) error
+
+	// Members returns the members whose records have not expired.
+	Members(ctx context.Context) ([]Member, error)
+}
+
+// Members returns the live participants in the election, ordered by identity.
+func (m *Manager) Members(ctx context.Context) ([]Member, error) {
+	ms, ok := m.backend.(Membership)
+	if !ok {
+		return nil, fmt.Errorf("%w: backend does not track members", errors.ErrUnsupported)
+	}
+
+	members, err := ms.Members(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	members = slices.DeleteFunc(members, func(member Member) bool { return member.Expired(now) })
+	for i := range members {
+		members[i].Identity, members[i].Session = SplitHolder(members[i].Identity)
+	}
+	slices.SortFunc(members, func(a, b Member) int {
+		return cmp.Or(strings.Compare(a.Identity, b.Identity), strings.Compare(a.Session, b.Session))
+	})
+	return members, nil
+}
+
+// heartbeat refreshes this manager's membership record once per renew interval.
+// The record outlives a couple of missed heartbeats. Failures are retried on the next tick.
+func (m *Manager) heartbeat(ctx context.Context) {
+	ms, ok := m.backend.(Membership)
+	if !ok || m.config.DisableMembership {
+		return
+	}
+
+	leaseDuration, renewInterval, _ := m.timing()
+	now := time.Now()
+	if now.Sub(m.lastHeartbeat) < renewInterval {
+		return
+	}
+
+	err := ms.Heartbeat(ctx, Member{
+		Identity: m.holder,
+		Address:  m.config.AdvertiseAddress,
+		Priority: m.config.Priority,
+		Version:  m.config.Version,
+		LastSeen: now,
+		TTL:      max(leaseDuration, 3*renewInterval),
+	})
+	if err == nil {
+		m.lastHeartbeat = now
+	}
+}
+
+// leaveMembership removes this manager's membership record if it wrote one.
+func (m *Manager) leaveMembership() {
+	ms, ok := m.backend.(Membership)
+	if !ok || m.lastHeartbeat.IsZero() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	if err := ms.Leave(ctx, m.holder); err == nil {
+		m.lastHeartbeat = time.Time{}
+	}
+}
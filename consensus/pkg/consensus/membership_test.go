@@ -0,0 +1,60 @@
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+	"github.com/fraser/consensus/pkg/consensus/backends/quorum"
+)
+
+func TestMembersListsEveryParticipant(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	ctx := context.Background()
+
+	start := func(identity string, priority int) *consensus.Manager {
+		config := fastConfig(identity)
+		config.Priority = priority
+		config.Version = "v1.2.3"
+		manager := consensus.NewManager(backend, config)
+		manager.Start(ctx)
+		return manager
+	}
+
+	a := start("a", 10)
+	defer a.Stop()
+	b := start("b", 5)
+
+	var members []consensus.Member
+	eventually(t, "both members", func() bool {
+		var err error
+		members, err = a.Members(ctx)
+		return err == nil && len(members) == 2
+	})
+	if m := members[0]; m.Identity != "a" || m.Priority != 10 || m.Version != "v1.2.3" {
+		t.Fatalf("members[0] = %+v, want a with priority 10", m)
+	}
+	if _, session := consensus.SplitHolder(b.HolderID()); members[1].Identity != "b" || members[1].Session != session {
+		t.Fatalf("members[1] = %+v, want b's session %q", members[1], session)
+	}
+
+	// A stopped manager leaves at once rather than waiting to expire
+	b.Stop()
+	members, err := a.Members(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Identity != "a" {
+		t.Fatalf("members after b stopped = %+v, want only a", members)
+	}
+}
+
+func TestMembersUnsupported(t *testing.T) {
+	manager := consensus.NewManager(quorum.NewBackend("self", nil), fastConfig("a"))
+	if _, err := manager.Members(context.Background()); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("err = %v, want ErrUnsupported", err)
+	}
+}
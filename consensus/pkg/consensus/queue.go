@@ -0,0 +1,99 @@
+package consensus
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// QueueEntry is one waiting candidate, as stored by CandidateQueue backends.
+type QueueEntry struct {
+	Holder string        `json:"holder"`
+	Since  time.Time     `json:"since"` // When the candidate joined the queue
+	Seen   time.Time     `json:"seen"`  // When the candidate last refreshed its entry
+	TTL    time.Duration `json:"ttl"`   // How long the entry lives without a refresh
+}
+
+// Queue is a FIFO of waiting candidates. Backends store it alongside the
+// lease and use these helpers so every backend orders and expires entries
+// the same way.
+type Queue []QueueEntry
+
+// Prune drops expired entries and reports whether any were dropped.
+func (q Queue) Prune(now time.Time) (Queue, bool) {
+	live := slices.DeleteFunc(slices.Clone(q), func(e QueueEntry) bool {
+		return now.Sub(e.Seen) > e.TTL
+	})
+	return live, len(live) != len(q)
+}
+
+// Enqueue appends holder or refreshes its entry, and prunes expired entries.
+// It reports whether the queue changed enough to be worth writing back: an
+// entry refreshed within the last half of its TTL is left alone.
+func (q Queue) Enqueue(holder string, ttl time.Duration, now time.Time) (Queue, bool) {
+	q, changed := q.Prune(now)
+
+	i := slices.IndexFunc(q, func(e QueueEntry) bool { return e.Holder == holder })
+	if i < 0 {
+		return append(q, QueueEntry{Holder: holder, Since: now, Seen: now, TTL: ttl}), true
+	}
+	if now.Sub(q[i].Seen) < ttl/2 && q[i].TTL == ttl {
+		return q, changed
+	}
+
+	q[i].Seen = now
+	q[i].TTL = ttl
+	return q, true
+}
+
+// Remove drops holder and reports whether it was queued.
+func (q Queue) Remove(holder string) (Queue, bool) {
+	out := slices.DeleteFunc(slices.Clone(q), func(e QueueEntry) bool { return e.Holder == holder })
+	return out, len(out) != len(q)
+}
+
+// Head returns the longest-waiting live holder, or "" if none.
+func (q Queue) Head(now time.Time) string {
+	for _, e := range q {
+		if now.Sub(e.Seen) <= e.TTL {
+			return e.Holder
+		}
+	}
+	return ""
+}
+
+// mayCampaign reports whether this manager may attempt acquisition now.
+// Without FairQueue, or with a backend that has no queue, it always may.
+// Queue errors also allow campaigning, so a broken queue cannot stall elections.
+func (m *Manager) mayCampaign(ctx context.Context, retryInterval time.Duration) bool {
+	cq, ok := m.backend.(CandidateQueue)
+	if !m.config.FairQueue || !ok {
+		return true
+	}
+
+	// Entries outlive a few missed retries
+	if err := cq.Enqueue(ctx, m.holder, 4*retryInterval); err != nil {
+		return true
+	}
+	m.queued = true
+
+	head, err := cq.QueueHead(ctx)
+	if err != nil {
+		return true
+	}
+	return head == "" || head == m.holder
+}
+
+// leaveQueue removes this manager from the candidate queue if it joined it.
+func (m *Manager) leaveQueue() {
+	cq, ok := m.backend.(CandidateQueue)
+	if !ok || !m.queued {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	if err := cq.Dequeue(ctx, m.holder); err == nil {
+		m.queued = false
+	}
+}
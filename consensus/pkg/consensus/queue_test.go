@@ -0,0 +1,79 @@
+package consensus_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+func TestQueueOrderAndExpiry(t *testing.T) {
+	now := time.Now()
+	var q consensus.Queue
+
+	q, _ = q.Enqueue("a", time.Second, now)
+	q, _ = q.Enqueue("b", time.Second, now.Add(100*time.Millisecond))
+	if head := q.Head(now); head != "a" {
+		t.Fatalf("head = %q, want a", head)
+	}
+
+	// A recent refresh is not worth a write and keeps a's place
+	if q2, changed := q.Enqueue("a", time.Second, now.Add(200*time.Millisecond)); changed || q2.Head(now) != "a" {
+		t.Fatalf("early refresh changed = %v, head = %q", changed, q2.Head(now))
+	}
+
+	// a stops refreshing and expires; b, still refreshed, moves up
+	later := now.Add(1500 * time.Millisecond)
+	q, _ = q.Enqueue("b", time.Second, later)
+	if head := q.Head(later); head != "b" {
+		t.Fatalf("head after expiry = %q, want b", head)
+	}
+	if q, removed := q.Remove("b"); !removed || q.Head(later) != "" {
+		t.Fatalf("remove = %v, head = %q", removed, q.Head(later))
+	}
+}
+
+func TestFairQueueHandsOverInArrivalOrder(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	ctx := context.Background()
+
+	start := func(identity string) (*consensus.Manager, *consensus.Lease) {
+		config := fastConfig(identity)
+		config.FairQueue = true
+		manager := consensus.NewManager(backend, config)
+		return manager, manager.Start(ctx)
+	}
+
+	a, aLease := start("a")
+	defer a.Stop()
+	eventually(t, "a to lead", aLease.IsLeader)
+
+	b, bLease := start("b")
+	defer b.Stop()
+	eventually(t, "b to queue", func() bool {
+		head, err := backend.QueueHead(ctx)
+		return err == nil && head == b.HolderID()
+	})
+
+	c, cLease := start("c")
+	defer c.Stop()
+	time.Sleep(100 * time.Millisecond)
+
+	a.Stop()
+	eventually(t, "b to lead", bLease.IsLeader)
+	if cLease.IsLeader() {
+		t.Fatal("c jumped the queue")
+	}
+
+	// b left the queue on winning, so c is next
+	head, err := backend.QueueHead(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != c.HolderID() {
+		t.Fatalf("queue head = %q, want c", head)
+	}
+}
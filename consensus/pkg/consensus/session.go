@@ -0,0 +1,72 @@
+package consensus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// holderSeparator joins an identity and its session in a holder ID.
+const holderSeparator = "#"
+
+// HolderID returns the holder ID a Manager stores in the backend for identity
+// and session: "identity#session".
+func HolderID(identity, session string) string {
+	if session == "" {
+		return identity
+	}
+	return identity + holderSeparator + session
+}
+
+// SplitHolder splits a holder ID into its identity and session. A holder
+// without a session (e.g. written by an operator's transfer) has an empty session.
+func SplitHolder(holder string) (identity, session string) {
+	i := strings.LastIndex(holder, holderSeparator)
+	if i < 0 {
+		return holder, ""
+	}
+	return holder[:i], holder[i+len(holderSeparator):]
+}
+
+// newSessionID returns a random per-process session ID.
+func newSessionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// claimTransfer takes over a lease an operator transferred to this manager's
+// identity. Transfers name the bare identity, which no running Manager holds,
+// so the holder is rewritten to this process's holder ID before acquiring.
+// Backends implementing Claimer rewrite it only if the bare identity still
+// holds the lease, so when several sessions share the identity exactly one
+// wins. Otherwise the claim is an unconditional Transfer: two such sessions
+// can both claim, and the first to acquire leads until its next renewal
+// fails against the other's claim.
+func (m *Manager) claimTransfer(ctx context.Context, leaseDuration time.Duration) bool {
+	lr, ok := m.backend.(LeaderReader)
+	if !ok {
+		return false
+	}
+
+	record, err := lr.GetLeader(ctx)
+	if err != nil || record == nil || record.Identity != m.config.Identity {
+		return false
+	}
+
+	switch backend := m.backend.(type) {
+	case Claimer:
+		err = backend.Claim(ctx, m.config.Identity, m.holder)
+	case Administrator:
+		err = backend.Transfer(ctx, m.holder)
+	default:
+		return false
+	}
+	if err != nil {
+		return false
+	}
+	acquired, err := m.tryAcquire(ctx, leaseDuration)
+	return err == nil && acquired
+}
@@ -0,0 +1,121 @@
+package consensus_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+func TestSplitHolder(t *testing.T) {
+	for _, tc := range []struct {
+		holder, identity, session string
+	}{
+		{"pod-a#1f2e", "pod-a", "1f2e"},
+		{"pod-a", "pod-a", ""},
+		{"team#1#abcd", "team#1", "abcd"},
+	} {
+		identity, session := consensus.SplitHolder(tc.holder)
+		if identity != tc.identity || session != tc.session {
+			t.Errorf("SplitHolder(%q) = %q, %q; want %q, %q", tc.holder, identity, session, tc.identity, tc.session)
+		}
+	}
+}
+
+func TestRestartedProcessDoesNotInheritLease(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	ctx := context.Background()
+
+	// A crashed predecessor with the same identity left a live lease behind
+	predecessor := consensus.NewManager(backend, fastConfig("pod-a"))
+	if ok, err := backend.TryAcquire(ctx, predecessor.HolderID(), time.Minute); err != nil || !ok {
+		t.Fatalf("setup: ok=%v err=%v", ok, err)
+	}
+
+	restarted := consensus.NewManager(backend, fastConfig("pod-a"))
+	if restarted.HolderID() == predecessor.HolderID() {
+		t.Fatal("two managers share a holder ID")
+	}
+	lease := restarted.Start(ctx)
+	defer restarted.Stop()
+
+	time.Sleep(200 * time.Millisecond)
+	if lease.IsLeader() {
+		t.Fatal("restarted process inherited its predecessor's lease")
+	}
+
+	leader, err := restarted.Leader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, session := consensus.SplitHolder(predecessor.HolderID()); leader.Identity != "pod-a" || leader.Session != session {
+		t.Fatalf("leader = %q session %q, want pod-a with the predecessor's session", leader.Identity, leader.Session)
+	}
+}
+
+func TestTransferIsClaimedByIdentity(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	ctx := context.Background()
+
+	a := consensus.NewManager(backend, fastConfig("a"))
+	aLease := a.Start(ctx)
+	defer a.Stop()
+	eventually(t, "a to lead", aLease.IsLeader)
+
+	b := consensus.NewManager(backend, fastConfig("b"))
+	bLease := b.Start(ctx)
+	defer b.Stop()
+
+	// Operators transfer to the plain identity
+	if err := backend.Transfer(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "b to claim the transfer", bLease.IsLeader)
+	eventually(t, "a to step down", func() bool { return !aLease.IsLeader() })
+
+	leader, err := backend.GetLeader(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leader.Identity != b.HolderID() {
+		t.Fatalf("holder = %q, want %q", leader.Identity, b.HolderID())
+	}
+}
+
+func TestTransferClaimedByOneSessionOfIdentity(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	ctx := context.Background()
+
+	if ok, err := backend.TryAcquire(ctx, "operator-held", time.Minute); err != nil || !ok {
+		t.Fatalf("setup: ok=%v err=%v", ok, err)
+	}
+
+	// Two processes on one host share the identity the lease is handed to
+	first := consensus.NewManager(backend, fastConfig("pod-a"))
+	firstLease := first.Start(ctx)
+	defer first.Stop()
+	second := consensus.NewManager(backend, fastConfig("pod-a"))
+	secondLease := second.Start(ctx)
+	defer second.Stop()
+
+	if err := backend.Transfer(ctx, "pod-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(500 * time.Millisecond)
+	claimed := false
+	for time.Now().Before(deadline) {
+		a, b := firstLease.IsLeader(), secondLease.IsLeader()
+		if a && b {
+			t.Fatal("both sessions lead after claiming the same transfer")
+		}
+		claimed = claimed || a || b
+		time.Sleep(time.Millisecond)
+	}
+	if !claimed {
+		t.Fatal("neither session claimed the transfer")
+	}
+}
@@ -0,0 +1,57 @@
+package shards
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strings"
+)
+
+// Assign maps every shard to one of members. No member is given more than
+// its fair share, ceil(len(shards)/len(members)) shards. Within that bound
+// each shard goes to the member with the highest rendezvous score for it, so
+// most shards keep their owner when a member joins or leaves. Every replica
+// computes the same assignment from the same member list, in any order.
+func Assign(shards, members []string) map[string]string {
+	members = slices.Compact(slices.Sorted(slices.Values(members)))
+	if len(members) == 0 {
+		return map[string]string{}
+	}
+	capacity := (len(shards) + len(members) - 1) / len(members)
+
+	type bid struct {
+		shard, member string
+		score         uint64
+	}
+	bids := make([]bid, 0, len(shards)*len(members))
+	for _, shard := range shards {
+		for _, member := range members {
+			bids = append(bids, bid{shard: shard, member: member, score: score(shard, member)})
+		}
+	}
+	slices.SortFunc(bids, func(a, b bid) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			strings.Compare(a.shard, b.shard),
+			strings.Compare(a.member, b.member),
+		)
+	})
+
+	owners := make(map[string]string, len(shards))
+	load := make(map[string]int, len(members))
+	for _, b := range bids {
+		if _, taken := owners[b.shard]; taken || load[b.member] >= capacity {
+			continue
+		}
+		owners[b.shard] = b.member
+		load[b.member]++
+	}
+	return owners
+}
+
+// score is member's rendezvous weight for shard.
+func score(shard, member string) uint64 {
+	sum := sha256.Sum256([]byte(shard + "\x00" + member))
+	return binary.BigEndian.Uint64(sum[:8])
+}
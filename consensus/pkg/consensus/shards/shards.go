@@ -0,0 +1,276 @@
+// Package shards spreads leadership of many partitions across replicas.
+// A Manager runs one election per shard and only campaigns for the shards
+// Assign gives it, based on the live members of the group, so each replica
+// leads a fair share and shards move when replicas join or leave.
+package shards
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+)
+
+// BackendFunc returns the backend holding the election for shard, e.g. a
+// Lease named after it. Every replica must map a shard to the same lease.
+type BackendFunc func(shard string) consensus.Backend
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithOnAcquire sets a callback run when this replica becomes leader of a
+// shard. ctx is cancelled when the shard is lost or released. The callback
+// runs on the shard's election loop, so long-running work belongs in a
+// goroutine bound to ctx.
+func WithOnAcquire(fn func(ctx context.Context, shard string)) Option {
+	return func(m *Manager) {
+		m.onAcquire = fn
+	}
+}
+
+// WithOnLose sets a callback run when this replica stops leading a shard,
+// whether it lost the lease or handed the shard to another replica.
+func WithOnLose(fn func(shard string)) Option {
+	return func(m *Manager) {
+		m.onLose = fn
+	}
+}
+
+// Manager bids for a fair share of shards on behalf of one replica.
+type Manager struct {
+	members   consensus.Membership
+	backends  BackendFunc
+	shards    []string
+	config    consensus.Config
+	holder    string
+	onAcquire func(ctx context.Context, shard string)
+	onLose    func(shard string)
+
+	cancel        context.CancelFunc
+	done          chan struct{}
+	elections     map[string]*consensus.Manager // Owned by the rebalance loop
+	lastHeartbeat time.Time
+
+	mu       sync.Mutex
+	baseCtx  context.Context
+	owned    map[string]context.CancelFunc
+	assigned []string
+}
+
+// New creates a shard manager. members records which replicas are live and
+// is shared by every replica; backends gives each shard its own election.
+// config supplies the identity and timing used for every shard's election.
+func New(members consensus.Membership, backends BackendFunc, shards []string, config consensus.Config, opts ...Option) *Manager {
+	m := &Manager{
+		members:  members,
+		backends: backends,
+		shards:   slices.Clone(shards),
+		config:   config,
+		holder:   consensus.HolderID(config.Identity, newSessionID()),
+		owned:    make(map[string]context.CancelFunc),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Start begins heartbeating and bidding for shards. It returns immediately.
+func (m *Manager) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	m.elections = make(map[string]*consensus.Manager)
+
+	m.mu.Lock()
+	m.baseCtx = ctx
+	m.mu.Unlock()
+
+	go m.run(ctx)
+}
+
+// Stop releases every shard this replica leads and leaves the group.
+// It returns once the shards have been released.
+func (m *Manager) Stop() error {
+	if m.cancel == nil {
+		return nil
+	}
+	m.cancel()
+	<-m.done
+	return nil
+}
+
+// Owned returns the shards this replica currently leads, sorted.
+func (m *Manager) Owned() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(m.owned))
+}
+
+// Assigned returns the shards this replica is bidding for, sorted.
+// A shard is assigned before it is owned: the previous owner has to release it first.
+func (m *Manager) Assigned() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.assigned)
+}
+
+// run rebalances on every retry interval until ctx is cancelled.
+func (m *Manager) run(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.config.RetryInterval)
+	defer ticker.Stop()
+
+	m.rebalance(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			for shard := range m.elections {
+				m.stopElection(shard)
+			}
+			m.leave()
+			return
+		case <-ticker.C:
+			m.rebalance(ctx)
+		}
+	}
+}
+
+// rebalance heartbeats, recomputes the assignment from the live members and
+// starts or stops shard elections to match. If the member list cannot be read
+// the current elections are kept.
+func (m *Manager) rebalance(ctx context.Context) {
+	m.heartbeat(ctx)
+
+	members, err := m.members.Members(ctx)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	identities := []string{m.config.Identity}
+	for _, member := range members {
+		if !member.Expired(now) {
+			identity, _ := consensus.SplitHolder(member.Identity)
+			identities = append(identities, identity)
+		}
+	}
+
+	var assigned []string
+	for shard, owner := range Assign(m.shards, identities) {
+		if owner == m.config.Identity {
+			assigned = append(assigned, shard)
+		}
+	}
+	slices.Sort(assigned)
+
+	m.mu.Lock()
+	m.assigned = assigned
+	m.mu.Unlock()
+
+	// Hand off first so the new owners are not kept waiting
+	for shard := range m.elections {
+		if !slices.Contains(assigned, shard) {
+			m.stopElection(shard)
+		}
+	}
+	for _, shard := range assigned {
+		if _, ok := m.elections[shard]; !ok {
+			m.startElection(ctx, shard)
+		}
+	}
+}
+
+// startElection begins campaigning for shard.
+func (m *Manager) startElection(ctx context.Context, shard string) {
+	config := m.config
+	config.Hooks = []consensus.Hook{m.hook(shard)}
+	config.DisableMembership = true
+	if config.Logger != nil {
+		config.Logger = config.Logger.With("shard", shard)
+	}
+
+	election := consensus.NewManager(m.backends(shard), config)
+	election.Start(ctx)
+	m.elections[shard] = election
+}
+
+// stopElection stops campaigning for shard, releasing it if held.
+func (m *Manager) stopElection(shard string) {
+	_ = m.elections[shard].Stop()
+	delete(m.elections, shard)
+}
+
+// hook translates a shard election's events into the shard callbacks.
+func (m *Manager) hook(shard string) consensus.Hook {
+	return consensus.HookFunc(func(_ context.Context, event consensus.Event) {
+		switch event.Type {
+		case consensus.EventAcquired:
+			m.mu.Lock()
+			ctx, cancel := context.WithCancel(m.baseCtx)
+			m.owned[shard] = cancel
+			m.mu.Unlock()
+
+			if m.onAcquire != nil {
+				m.onAcquire(ctx, shard)
+			}
+
+		case consensus.EventLost, consensus.EventReleased:
+			m.mu.Lock()
+			cancel, ok := m.owned[shard]
+			delete(m.owned, shard)
+			m.mu.Unlock()
+
+			if !ok {
+				return
+			}
+			cancel()
+			if m.onLose != nil {
+				m.onLose(shard)
+			}
+		}
+	})
+}
+
+// heartbeat refreshes this replica's membership record once per renew interval.
+func (m *Manager) heartbeat(ctx context.Context) {
+	now := time.Now()
+	if now.Sub(m.lastHeartbeat) < m.config.RenewInterval {
+		return
+	}
+
+	err := m.members.Heartbeat(ctx, consensus.Member{
+		Identity: m.holder,
+		Address:  m.config.AdvertiseAddress,
+		Priority: m.config.Priority,
+		Version:  m.config.Version,
+		LastSeen: now,
+		TTL:      max(m.config.LeaseDuration, 3*m.config.RenewInterval),
+	})
+	if err == nil {
+		m.lastHeartbeat = now
+	}
+}
+
+// leave removes this replica's membership record so its shards are
+// reassigned without waiting for the record to expire.
+func (m *Manager) leave() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = m.members.Leave(ctx, m.holder)
+}
+
+// newSessionID returns a random per-process session ID.
+func newSessionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,107 @@
+package shards
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+func TestAssignIsFairAndOrderIndependent(t *testing.T) {
+	var shards []string
+	for i := range 10 {
+		shards = append(shards, fmt.Sprintf("shard-%d", i))
+	}
+
+	owners := Assign(shards, []string{"a", "b", "c"})
+	load := map[string]int{}
+	for _, shard := range shards {
+		owner, ok := owners[shard]
+		if !ok {
+			t.Fatalf("%s unassigned", shard)
+		}
+		load[owner]++
+	}
+	for member, n := range load {
+		if n > 4 {
+			t.Errorf("%s owns %d shards, fair share is at most 4", member, n)
+		}
+	}
+
+	shuffled := Assign(shards, []string{"c", "a", "b", "a"})
+	for _, shard := range shards {
+		if owners[shard] != shuffled[shard] {
+			t.Fatalf("%s assigned to %s and %s depending on member order", shard, owners[shard], shuffled[shard])
+		}
+	}
+}
+
+func fastConfig(identity string) consensus.Config {
+	return consensus.Config{
+		Identity:      identity,
+		LeaseDuration: time.Second,
+		RenewInterval: 20 * time.Millisecond,
+		RetryInterval: 20 * time.Millisecond,
+	}
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReplicasSplitAndHandOffShards(t *testing.T) {
+	dir := t.TempDir()
+	members := file.NewBackend(filepath.Join(dir, "members.json"))
+	backends := func(shard string) consensus.Backend {
+		return file.NewBackend(filepath.Join(dir, shard+".json"))
+	}
+	shards := []string{"p0", "p1", "p2", "p3", "p4", "p5"}
+
+	var mu sync.Mutex
+	lost := map[string]int{}
+	onLose := WithOnLose(func(shard string) {
+		mu.Lock()
+		lost[shard]++
+		mu.Unlock()
+	})
+
+	a := New(members, backends, shards, fastConfig("a"), onLose)
+	a.Start(context.Background())
+	defer a.Stop()
+	eventually(t, "a to own every shard", func() bool { return len(a.Owned()) == len(shards) })
+
+	b := New(members, backends, shards, fastConfig("b"))
+	b.Start(context.Background())
+	eventually(t, "an even split", func() bool {
+		return len(a.Owned()) == 3 && len(b.Owned()) == 3
+	})
+	for _, shard := range b.Owned() {
+		if slices.Contains(a.Owned(), shard) {
+			t.Fatalf("%s owned by both replicas", shard)
+		}
+	}
+
+	mu.Lock()
+	handedOff := len(lost)
+	mu.Unlock()
+	if handedOff != 3 {
+		t.Fatalf("a lost %d shards, want 3", handedOff)
+	}
+
+	// b leaves the group, so a takes its shards back
+	b.Stop()
+	eventually(t, "a to own every shard again", func() bool { return len(a.Owned()) == len(shards) })
+}
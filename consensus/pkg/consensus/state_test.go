@@ -0,0 +1,46 @@
+package consensus_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+func TestStateSurvivesLeadershipChange(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	ctx := context.Background()
+
+	first := consensus.NewManager(backend, fastConfig("a"))
+	lease := first.Start(ctx)
+	eventually(t, "a leads", lease.IsLeader)
+
+	if err := lease.SetState([]byte("offset=42")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(lease.State()); got != "offset=42" {
+		t.Fatalf("State() = %q after SetState, want offset=42", got)
+	}
+
+	second := consensus.NewManager(backend, fastConfig("b"))
+	next := second.Start(ctx)
+	defer second.Stop()
+	if err := next.SetState([]byte("too early")); !errors.Is(err, consensus.ErrNotLeader) {
+		t.Fatalf("follower SetState: got %v, want ErrNotLeader", err)
+	}
+
+	if err := first.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "b leads", next.IsLeader)
+
+	if got := string(next.State()); got != "offset=42" {
+		t.Fatalf("new leader State() = %q, want its predecessor's offset=42", got)
+	}
+	if err := lease.SetState([]byte("stale")); !errors.Is(err, consensus.ErrNotLeader) {
+		t.Fatalf("deposed SetState: got %v, want ErrNotLeader", err)
+	}
+}
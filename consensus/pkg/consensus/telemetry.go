@@ -0,0 +1,108 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package's tracer.
+const instrumentationName = "github.com/fraser/consensus/pkg/consensus"
+
+// newLogger returns config's logger, or one that discards, carrying the
+// fields every election log line shares.
+func newLogger(config Config, backend Backend, holder string) *slog.Logger {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	return logger.With("identity", config.Identity, "holder", holder, "backend", backendName(backend))
+}
+
+// newTracer returns a tracer from config's provider, or the global one.
+func newTracer(config Config) trace.Tracer {
+	provider := config.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+// backendName describes backend by its type, e.g. "*file.Backend".
+func backendName(backend Backend) string {
+	return fmt.Sprintf("%T", backend)
+}
+
+// tryAcquire calls Backend.TryAcquire inside a span.
+func (m *Manager) tryAcquire(ctx context.Context, leaseDuration time.Duration) (bool, error) {
+	ctx, span := m.startSpan(ctx, "TryAcquire", leaseDuration)
+	defer span.End()
+
+	acquired, err := m.backend.TryAcquire(ctx, m.holder, leaseDuration)
+	span.SetAttributes(attribute.Bool("consensus.acquired", acquired))
+	endSpan(span, err)
+	return acquired, err
+}
+
+// renew calls Backend.Renew inside a span.
+func (m *Manager) renew(ctx context.Context, leaseDuration time.Duration) error {
+	ctx, span := m.startSpan(ctx, "Renew", leaseDuration)
+	defer span.End()
+
+	err := m.backend.Renew(ctx, m.holder, leaseDuration)
+	endSpan(span, err)
+	return err
+}
+
+// releaseLease calls Backend.Release inside a span.
+func (m *Manager) releaseLease(ctx context.Context) error {
+	ctx, span := m.startSpan(ctx, "Release", 0)
+	defer span.End()
+
+	err := m.backend.Release(ctx, m.holder)
+	endSpan(span, err)
+	return err
+}
+
+// startSpan starts a span for a backend operation with the leadership attributes.
+func (m *Manager) startSpan(ctx context.Context, operation string, leaseDuration time.Duration) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("consensus.identity", m.config.Identity),
+		attribute.String("consensus.holder", m.holder),
+		attribute.String("consensus.backend", backendName(m.backend)),
+		attribute.Bool("consensus.is_leader", m.lease.IsLeader()),
+		attribute.Int64("consensus.term", int64(m.lease.Term())),
+	}
+	if leaseDuration > 0 {
+		attrs = append(attrs, attribute.String("consensus.lease_duration", leaseDuration.String()))
+	}
+	return m.tracer.Start(ctx, "consensus."+operation, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// logEvent logs a leadership transition at a level matching its severity.
+func (m *Manager) logEvent(event Event) {
+	level := slog.LevelInfo
+	if event.Type == EventLost || event.Type == EventRenewFailed {
+		level = slog.LevelWarn
+	}
+
+	attrs := []any{"event", string(event.Type), "term", m.lease.Term()}
+	if event.Err != nil {
+		attrs = append(attrs, "error", event.Err)
+	}
+	m.log.Log(context.Background(), level, "leadership "+string(event.Type), attrs...)
+}
@@ -0,0 +1,100 @@
+package consensus_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/fraser/consensus/pkg/consensus"
+	"github.com/fraser/consensus/pkg/consensus/backends/file"
+)
+
+// syncBuffer is a bytes.Buffer safe for the election loop and the test to share.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) lines() []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(b.buf.Bytes()), []byte("\n")) {
+		var entry map[string]any
+		if json.Unmarshal(line, &entry) == nil {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+func TestLeadershipIsLoggedAndTraced(t *testing.T) {
+	backend := file.NewBackend(filepath.Join(t.TempDir(), "lease.json"))
+	recorder := tracetest.NewSpanRecorder()
+	var logs syncBuffer
+
+	config := fastConfig("a")
+	config.Logger = slog.New(slog.NewJSONHandler(&logs, nil))
+	config.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	manager := consensus.NewManager(backend, config)
+	lease := manager.Start(context.Background())
+	eventually(t, "leadership", lease.IsLeader)
+	eventually(t, "a renewal", func() bool {
+		for _, span := range recorder.Ended() {
+			if span.Name() == "consensus.Renew" {
+				return true
+			}
+		}
+		return false
+	})
+	manager.Stop()
+
+	var acquired, released map[string]any
+	for _, entry := range logs.lines() {
+		switch entry["event"] {
+		case "Acquired":
+			acquired = entry
+		case "Released":
+			released = entry
+		}
+	}
+	if acquired == nil || acquired["identity"] != "a" || acquired["holder"] != manager.HolderID() || acquired["backend"] != "*file.Backend" {
+		t.Fatalf("acquired log = %v, want identity, holder and backend fields", acquired)
+	}
+	if released == nil {
+		t.Fatal("no log line for the release on Stop")
+	}
+
+	spans := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = true
+		if span.Name() != "consensus.TryAcquire" {
+			continue
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == "consensus.identity" && attr.Value.AsString() != "a" {
+				t.Errorf("span identity = %q, want a", attr.Value.AsString())
+			}
+		}
+	}
+	for _, name := range []string{"consensus.TryAcquire", "consensus.Renew", "consensus.Release"} {
+		if !spans[name] {
+			t.Errorf("no %s span recorded; got %v", name, spans)
+		}
+	}
+}